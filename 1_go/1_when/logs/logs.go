@@ -1,6 +1,10 @@
 package logs
 
-import "time"
+import (
+	"time"
+
+	"goiface/2_design/clock"
+)
 
 type Level byte
 
@@ -16,12 +20,21 @@ type Log struct {
 	Message string
 }
 
+//go:generate go run goiface/2_design/mockgen -out mock_db_test.go DB
+
 type DB interface {
 	Query(start, end time.Time) ([]Log, error)
 }
 
 type Logs struct {
-	db DB
+	db    DB
+	clock clock.Clock
+}
+
+// New returns a Logs querying db, clk is the time source (nil is the system
+// clock).
+func New(db DB, clk clock.Clock) *Logs {
+	return &Logs{db: db, clock: clock.Or(clk)}
 }
 
 func (ls *Logs) Query(start, end time.Time, level Level) ([]Log, error) {
@@ -39,3 +52,9 @@ func (ls *Logs) Query(start, end time.Time, level Level) ([]Log, error) {
 
 	return logs, nil
 }
+
+// Recent returns logs from the last d with level or above.
+func (ls *Logs) Recent(d time.Duration, level Level) ([]Log, error) {
+	end := clock.Or(ls.clock).Now()
+	return ls.Query(end.Add(-d), end, level)
+}
@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"goiface/2_design/clock"
+	"goiface/2_design/mockgen/mock"
 )
 
 type mockDB struct{}
@@ -21,3 +24,51 @@ func TestLogs_QueryError(t *testing.T) {
 	_, err := ls.Query(start, end, InfoLevel)
 	require.Error(t, err)
 }
+
+type recordDB struct {
+	start, end time.Time
+	logs       []Log
+}
+
+func (r *recordDB) Query(start, end time.Time) ([]Log, error) {
+	r.start, r.end = start, end
+	return r.logs, nil
+}
+
+func TestLogs_Recent(t *testing.T) {
+	now := time.Date(2024, 2, 11, 20, 27, 31, 0, time.UTC)
+	db := recordDB{
+		logs: []Log{
+			{Time: now.Add(-time.Minute), Level: InfoLevel, Message: "login"},
+			{Time: now.Add(-time.Second), Level: ErrorLevel, Message: "disk full"},
+		},
+	}
+	ls := New(&db, clock.NewFake(now))
+
+	logs, err := ls.Recent(time.Hour, WarningLevel)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(-time.Hour), db.start)
+	require.Equal(t, now, db.end)
+	require.Equal(t, db.logs[1:], logs)
+}
+
+func TestLogs_QueryLevel(t *testing.T) {
+	now := time.Date(2024, 2, 11, 20, 27, 31, 0, time.UTC)
+	start := now.Add(-time.Hour)
+	db := NewMockDB(t)
+	db.OnQuery(start, now).Return([]Log{
+		{Time: now.Add(-time.Minute), Level: InfoLevel, Message: "login"},
+		{Time: now.Add(-time.Second), Level: ErrorLevel, Message: "disk full"},
+	}, nil).Times(1)
+	db.OnQuery(mock.Any(), mock.Any()).Return(nil, fmt.Errorf("no connection"))
+
+	ls := New(db, clock.NewFake(now))
+	logs, err := ls.Query(start, now, ErrorLevel)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, "disk full", logs[0].Message)
+
+	_, err = ls.Query(start, now, ErrorLevel)
+	require.Error(t, err)
+	db.AssertNumberOfCalls(t, "Query", 2)
+}
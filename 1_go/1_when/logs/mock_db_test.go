@@ -0,0 +1,49 @@
+// Code generated by mockgen. DO NOT EDIT.
+
+package logs
+
+import (
+	"time"
+
+	"goiface/2_design/mockgen/mock"
+)
+
+// MockDB is a mock DB.
+type MockDB struct {
+	mock.Mock
+}
+
+// NewMockDB returns a MockDB, its expectations are checked when t finishes.
+func NewMockDB(t mock.T) *MockDB {
+	var m MockDB
+	m.Mock.Init(t)
+	return &m
+}
+
+// Query implements DB.
+func (m *MockDB) Query(start time.Time, end time.Time) ([]Log, error) {
+	rets := m.Mock.Called("Query", start, end)
+	return mock.Ret[[]Log](rets, 0), mock.Ret[error](rets, 1)
+}
+
+// MockDBQueryCall is an expected Query call.
+type MockDBQueryCall struct {
+	*mock.Call
+}
+
+// OnQuery expects a Query call, arguments are values or mock.Matcher.
+func (m *MockDB) OnQuery(start, end any) MockDBQueryCall {
+	return MockDBQueryCall{m.Mock.On("Query", start, end)}
+}
+
+// Return adds return values to the call sequence.
+func (c MockDBQueryCall) Return(r0 []Log, r1 error) MockDBQueryCall {
+	c.Call.Return(r0, r1)
+	return c
+}
+
+// Times sets the exact number of expected calls.
+func (c MockDBQueryCall) Times(n int) MockDBQueryCall {
+	c.Call.Times(n)
+	return c
+}
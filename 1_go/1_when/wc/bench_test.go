@@ -0,0 +1,38 @@
+package wc
+
+import (
+	"flag"
+	"io"
+	"os"
+	"testing"
+)
+
+var benchSize = flag.Int64("wc.size", 256<<20, "size of the BenchmarkLineCount file, use a few GB to measure large inputs")
+
+func BenchmarkLineCount(b *testing.B) {
+	fileName, want := writeLines(b, *benchSize)
+
+	count := func(b *testing.B, wrap func(*os.File) io.Reader) {
+		b.SetBytes(*benchSize)
+		for i := 0; i < b.N; i++ {
+			file, err := os.Open(fileName)
+			if err != nil {
+				b.Fatal(err)
+			}
+			n, err := LineCount(wrap(file))
+			file.Close()
+			if err != nil || n != want {
+				b.Fatalf("got %d, %v, want %d", n, err, want)
+			}
+		}
+	}
+
+	b.Run("mmap", func(b *testing.B) {
+		count(b, func(f *os.File) io.Reader { return f })
+	})
+
+	b.Run("Scanner", func(b *testing.B) {
+		// Hide the *os.File to disable the mmap path
+		count(b, func(f *os.File) io.Reader { return struct{ io.Reader }{f} })
+	})
+}
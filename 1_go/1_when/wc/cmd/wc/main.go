@@ -0,0 +1,75 @@
+/*
+Wc counts lines, words, runes and bytes of the files in a directory tree.
+
+Usage:
+
+	wc [-include LIST] [-exclude LIST] [-ignore LIST] [-archives] [-format FORMAT] [DIR]
+
+It walks DIR (default "."), skipping the paths matching the rules of the
+.gitignore files (-ignore), and prints the counts by file extension. LIST is
+a comma separated list of patterns such as "*.go,docs/*.md". With
+-archives, it counts the files inside tar, tar.gz and zip archives. UTF-16
+and Latin-1 files are detected and transcoded to count their runes and words.
+
+FORMAT is table, json or markdown.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"goiface/1_go/1_when/wc"
+)
+
+func main() {
+	include := flag.String("include", "", "count only files matching `LIST` patterns")
+	exclude := flag.String("exclude", "", "skip files and directories matching `LIST` patterns")
+	ignore := flag.String("ignore", ".gitignore", "ignore files `LIST`")
+	archives := flag.Bool("archives", false, "count files in archives")
+	format := flag.String("format", string(wc.FormatTable), "output `FORMAT` (table, json or markdown)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [options] [DIR]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		dir = flag.Arg(0)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := []wc.Option{
+		wc.Include(split(*include)...),
+		wc.Exclude(split(*exclude)...),
+		wc.IgnoreFiles(split(*ignore)...),
+	}
+	if *archives {
+		opts = append(opts, wc.Archives())
+	}
+
+	r, err := wc.CountFS(os.DirFS(dir), ".", opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+
+	if err := r.Write(os.Stdout, wc.Format(*format)); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func split(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
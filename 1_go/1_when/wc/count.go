@@ -0,0 +1,123 @@
+package wc
+
+import (
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Counts are line, word, rune and byte counts.
+type Counts struct {
+	Lines int64 `json:"lines"`
+	Words int64 `json:"words"`
+	Runes int64 `json:"runes"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Add adds o to c.
+func (c *Counts) Add(o Counts) {
+	c.Lines += o.Lines
+	c.Words += o.Words
+	c.Runes += o.Runes
+	c.Bytes += o.Bytes
+}
+
+// Count returns the counts of the UTF-8 text in r. Like LineCount, a last
+// line without a newline is counted. Words are separated by Unicode white
+// space, an invalid UTF-8 byte counts as one rune.
+func Count(r io.Reader) (Counts, error) {
+	var (
+		c      Counts
+		inWord bool
+		last   byte = '\n'
+	)
+
+	buf := make([]byte, 32<<10)
+	carry := 0 // Start of an incomplete rune at the end of buf
+	for {
+		n, err := r.Read(buf[carry:])
+		c.Bytes += int64(n)
+		n += carry
+		if n > 0 {
+			last = buf[n-1]
+		}
+
+		data := buf[:n]
+		i := 0
+		for i < len(data) {
+			b := data[i]
+			if b < utf8.RuneSelf {
+				i++
+				c.Runes++
+				switch b {
+				case '\n':
+					c.Lines++
+					inWord = false
+				case ' ', '\t', '\v', '\f', '\r':
+					inWord = false
+				default:
+					if !inWord {
+						c.Words++
+						inWord = true
+					}
+				}
+				continue
+			}
+
+			if err == nil && !utf8.FullRune(data[i:]) {
+				break // Rest of the rune in the next read
+			}
+			r, size := utf8.DecodeRune(data[i:])
+			i += size
+			c.Runes++
+			if unicode.IsSpace(r) {
+				inWord = false
+			} else if !inWord {
+				c.Words++
+				inWord = true
+			}
+		}
+		carry = copy(buf, data[i:])
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Counts{}, err
+		}
+	}
+
+	if last != '\n' && c.Bytes > 0 {
+		c.Lines++
+	}
+	return c, nil
+}
+
+// CountText returns the counts of the text in r, and its encoding detected
+// with DetectEncoding. Lines, words and runes are counted in the text
+// transcoded to UTF-8, bytes in r, including the byte order mark.
+func CountText(r io.Reader) (Counts, Encoding, error) {
+	cr := countReader{r: r}
+	tr, enc, err := NewUTF8Reader(&cr)
+	if err != nil {
+		return Counts{}, "", err
+	}
+
+	c, err := Count(tr)
+	if err != nil {
+		return Counts{}, "", err
+	}
+	c.Bytes = cr.n
+	return c, enc, nil
+}
+
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,206 @@
+package wc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is a text encoding.
+type Encoding string
+
+// Supported encodings.
+const (
+	UTF8    Encoding = "utf-8"
+	UTF16LE Encoding = "utf-16le"
+	UTF16BE Encoding = "utf-16be"
+	Latin1  Encoding = "iso-8859-1"
+)
+
+var boms = []struct {
+	bom []byte
+	enc Encoding
+}{
+	{[]byte{0xef, 0xbb, 0xbf}, UTF8},
+	{[]byte{0xff, 0xfe}, UTF16LE},
+	{[]byte{0xfe, 0xff}, UTF16BE},
+}
+
+// sniffLen is how many bytes DetectEncoding looks at.
+const sniffLen = 4096
+
+// DetectEncoding guesses the encoding of a text from its start, it returns
+// the encoding and the length of its byte order mark (BOM), 0 if there is
+// none. Without BOM, text with many NUL bytes at even or odd positions is
+// UTF-16, valid UTF-8 is UTF-8 and anything else is Latin-1.
+func DetectEncoding(data []byte) (Encoding, int) {
+	for _, b := range boms {
+		if bytes.HasPrefix(data, b.bom) {
+			return b.enc, len(b.bom)
+		}
+	}
+
+	data = data[:min(len(data), sniffLen)]
+	if enc, ok := detectUTF16(data); ok {
+		return enc, 0
+	}
+
+	// The sample might end in the middle of a rune
+	tail := max(len(data)-utf8.UTFMax+1, 0)
+	for i := len(data) - 1; i >= tail; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				data = data[:i]
+			}
+			break
+		}
+	}
+	if utf8.Valid(data) {
+		return UTF8, 0
+	}
+	return Latin1, 0
+}
+
+// detectUTF16 detects UTF-16 text mostly in the ASCII range, where one byte of
+// every code unit is 0.
+func detectUTF16(data []byte) (Encoding, bool) {
+	units := len(data) / 2
+	if units == 0 {
+		return "", false
+	}
+
+	var even, odd int
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == 0 {
+			even++
+		}
+		if data[i+1] == 0 {
+			odd++
+		}
+	}
+
+	switch {
+	case odd > units/4 && even == 0:
+		return UTF16LE, true
+	case even > units/4 && odd == 0:
+		return UTF16BE, true
+	}
+	return "", false
+}
+
+// NewUTF8Reader detects the encoding of r, see DetectEncoding, and returns a
+// reader of its text in UTF-8 without byte order mark.
+func NewUTF8Reader(r io.Reader) (io.Reader, Encoding, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	data, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", err
+	}
+
+	enc, bomLen := DetectEncoding(data)
+	br.Discard(bomLen)
+	dr, err := NewDecoder(br, enc)
+	if err != nil {
+		return nil, "", err
+	}
+	return dr, enc, nil
+}
+
+// NewDecoder returns a reader of the text in r, in encoding enc, transcoded to
+// UTF-8. Invalid UTF-16 is decoded as U+FFFD.
+func NewDecoder(r io.Reader, enc Encoding) (io.Reader, error) {
+	switch enc {
+	case UTF8:
+		return r, nil
+	case UTF16LE, UTF16BE, Latin1:
+		return &decoder{r: r, enc: enc}, nil
+	}
+	return nil, fmt.Errorf("wc: unknown encoding - %q", enc)
+}
+
+type decoder struct {
+	r   io.Reader
+	enc Encoding
+	err error
+
+	buf []byte // Read buffer
+	in  []byte // Undecoded input, an incomplete UTF-16 character
+	out []byte // Decoded output not read yet
+	dec []byte // out buffer
+}
+
+// Read implements io.Reader
+func (d *decoder) Read(p []byte) (int, error) {
+	if d.buf == nil {
+		d.buf = make([]byte, 4096)
+	}
+
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+
+		n, err := d.r.Read(d.buf)
+		d.in = append(d.in, d.buf[:n]...)
+		d.err = err
+		d.decode(err != nil)
+	}
+
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// decode decodes d.in to d.out. If final, incomplete input is decoded as
+// U+FFFD.
+func (d *decoder) decode(final bool) {
+	out := d.dec[:0]
+	in := d.in
+
+	if d.enc == Latin1 {
+		for _, b := range in {
+			out = utf8.AppendRune(out, rune(b))
+		}
+		in = in[len(in):]
+	} else {
+		for len(in) >= 2 {
+			r := d.unit(in)
+			if !utf16.IsSurrogate(r) {
+				out = utf8.AppendRune(out, r)
+				in = in[2:]
+				continue
+			}
+
+			if len(in) < 4 {
+				break // Wait for the second half of the pair
+			}
+			if r2 := utf16.DecodeRune(r, d.unit(in[2:])); r2 != utf8.RuneError {
+				out = utf8.AppendRune(out, r2)
+				in = in[4:]
+				continue
+			}
+			out = utf8.AppendRune(out, utf8.RuneError)
+			in = in[2:]
+		}
+	}
+
+	if final && len(in) > 0 {
+		out = utf8.AppendRune(out, utf8.RuneError)
+		in = in[len(in):]
+	}
+
+	d.in = append(d.in[:0], in...)
+	d.dec = out
+	d.out = out
+}
+
+// unit returns the UTF-16 code unit at the start of data.
+func (d *decoder) unit(data []byte) rune {
+	if d.enc == UTF16LE {
+		return rune(data[0]) | rune(data[1])<<8
+	}
+	return rune(data[0])<<8 | rune(data[1])
+}
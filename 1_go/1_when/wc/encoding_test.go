@@ -0,0 +1,207 @@
+package wc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+const text = "Ça va? Très bien.\nΚαλημέρα κόσμε 👋\n"
+
+func encodeUTF16(s string, order binary.ByteOrder, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xfeff}, units...)
+	}
+	data := make([]byte, 2*len(units))
+	for i, u := range units {
+		order.PutUint16(data[2*i:], u)
+	}
+	return data
+}
+
+func encodeLatin1(s string) []byte {
+	var data []byte
+	for _, r := range s {
+		data = append(data, byte(r))
+	}
+	return data
+}
+
+func TestDetectEncoding(t *testing.T) {
+	latin1 := "Ça va? Très bien, à côté.\n"
+
+	testCases := []struct {
+		name   string
+		data   []byte
+		enc    Encoding
+		bomLen int
+	}{
+		{"empty", nil, UTF8, 0},
+		{"ascii", []byte("hello\n"), UTF8, 0},
+		{"utf-8", []byte(text), UTF8, 0},
+		{"utf-8 bom", append([]byte{0xef, 0xbb, 0xbf}, text...), UTF8, 3},
+		{"utf-16le bom", encodeUTF16(text, binary.LittleEndian, true), UTF16LE, 2},
+		{"utf-16be bom", encodeUTF16(text, binary.BigEndian, true), UTF16BE, 2},
+		{"utf-16le", encodeUTF16(latin1, binary.LittleEndian, false), UTF16LE, 0},
+		{"utf-16be", encodeUTF16(latin1, binary.BigEndian, false), UTF16BE, 0},
+		{"latin-1", encodeLatin1(latin1), Latin1, 0},
+		// Sample ends in the middle of a rune
+		{"utf-8 cut", []byte(strings.Repeat("é", sniffLen)), UTF8, 0},
+		{"utf-8 short", []byte(strings.Repeat("a", sniffLen-1) + "é"), UTF8, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			enc, bomLen := DetectEncoding(tc.data)
+			if enc != tc.enc || bomLen != tc.bomLen {
+				t.Fatalf("got %s/%d, want %s/%d", enc, bomLen, tc.enc, tc.bomLen)
+			}
+		})
+	}
+}
+
+func TestNewUTF8Reader(t *testing.T) {
+	testCases := []struct {
+		name string
+		data []byte
+		enc  Encoding
+	}{
+		{"utf-8", []byte(text), UTF8},
+		{"utf-8 bom", append([]byte{0xef, 0xbb, 0xbf}, text...), UTF8},
+		{"utf-16le", encodeUTF16(text, binary.LittleEndian, true), UTF16LE},
+		{"utf-16be", encodeUTF16(text, binary.BigEndian, true), UTF16BE},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// One byte at a time splits code units and surrogate pairs
+			r, enc, err := NewUTF8Reader(iotest.OneByteReader(bytes.NewReader(tc.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if enc != tc.enc {
+				t.Fatalf("encoding: got %s, want %s", enc, tc.enc)
+			}
+			out, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != text {
+				t.Fatalf("got %q, want %q", out, text)
+			}
+		})
+	}
+
+	r, err := NewDecoder(bytes.NewReader(encodeLatin1("à côté")), Latin1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := io.ReadAll(r); string(out) != "à côté" {
+		t.Fatalf("latin-1: got %q", out)
+	}
+
+	// Lone surrogate and odd byte
+	r, err = NewDecoder(bytes.NewReader([]byte{'a', 0, 0x00, 0xd8, 'b', 0, 'c'}), UTF16LE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := io.ReadAll(r); string(out) != "a�b�" {
+		t.Fatalf("invalid: got %q", out)
+	}
+
+	if _, err := NewDecoder(nil, "ebcdic"); err == nil {
+		t.Fatal("unknown encoding: no error")
+	}
+}
+
+func TestCountText(t *testing.T) {
+	want := Counts{Lines: 2, Words: 7, Runes: 35}
+
+	testCases := []struct {
+		name string
+		data []byte
+		enc  Encoding
+	}{
+		{"utf-8", []byte(text), UTF8},
+		{"utf-16le", encodeUTF16(text, binary.LittleEndian, true), UTF16LE},
+		{"utf-16be", encodeUTF16(text, binary.BigEndian, true), UTF16BE},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, enc, err := CountText(iotest.HalfReader(bytes.NewReader(tc.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if enc != tc.enc {
+				t.Fatalf("encoding: got %s, want %s", enc, tc.enc)
+			}
+			want.Bytes = int64(len(tc.data))
+			if c != want {
+				t.Fatalf("got %+v, want %+v", c, want)
+			}
+		})
+	}
+
+	latin1 := "Ça va? Très bien.\n"
+	c, enc, err := CountText(bytes.NewReader(encodeLatin1(latin1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = Counts{Lines: 1, Words: 4, Runes: 18, Bytes: 18}
+	if enc != Latin1 || c != want {
+		t.Fatalf("latin-1: got %s %+v, want %+v", enc, c, want)
+	}
+
+	_, _, err = CountText(iotest.ErrReader(io.ErrClosedPipe))
+	if err != io.ErrClosedPipe {
+		t.Fatalf("error: got %v", err)
+	}
+}
+
+func TestCount_SplitRunes(t *testing.T) {
+	c, err := Count(iotest.OneByteReader(strings.NewReader(text + "\xff")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Counts{Lines: 3, Words: 8, Runes: 36, Bytes: int64(len(text)) + 1}
+	if c != want {
+		t.Fatalf("got %+v, want %+v", c, want)
+	}
+}
+
+func TestCountFS_Encodings(t *testing.T) {
+	fsys := fstest.MapFS{
+		"utf8.txt":  {Data: []byte(text)},
+		"utf16.txt": {Data: encodeUTF16(text, binary.LittleEndian, true)},
+		"old.txt":   {Data: encodeLatin1("Ça va?\n")},
+	}
+
+	r, err := CountFS(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encs := make(map[string]Encoding)
+	for _, f := range r.Files {
+		encs[f.Path] = f.Encoding
+		if f.Encoding != Latin1 && f.Runes != 35 {
+			t.Errorf("%s: runes %d", f.Path, f.Runes)
+		}
+	}
+	want := map[string]Encoding{"utf8.txt": UTF8, "utf16.txt": UTF16LE, "old.txt": Latin1}
+	for name, enc := range want {
+		if encs[name] != enc {
+			t.Errorf("%s: got %s, want %s", name, encs[name], enc)
+		}
+	}
+	if r.Encodings[UTF8] != 1 || r.Encodings[UTF16LE] != 1 || r.Encodings[Latin1] != 1 {
+		t.Errorf("encodings: %v", r.Encodings)
+	}
+}
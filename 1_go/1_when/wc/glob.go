@@ -0,0 +1,110 @@
+package wc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// glob is a gitignore style pattern. A pattern with a slash, other than a
+// trailing one, is anchored: it matches the whole path. Otherwise it matches
+// the base name. "**" matches any number of path elements.
+type glob struct {
+	parts    []string
+	anchored bool
+	dirOnly  bool // Trailing slash
+}
+
+func compileGlob(pattern string) (glob, error) {
+	var g glob
+	if strings.HasSuffix(pattern, "/") {
+		g.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if strings.Contains(pattern, "/") {
+		g.anchored = true
+		pattern = strings.TrimPrefix(pattern, "/")
+	}
+	if pattern == "" {
+		return glob{}, fmt.Errorf("%w: empty pattern", path.ErrBadPattern)
+	}
+
+	g.parts = strings.Split(pattern, "/")
+	for _, part := range g.parts {
+		if _, err := path.Match(part, ""); err != nil {
+			return glob{}, fmt.Errorf("%w: %q", err, pattern)
+		}
+	}
+	return g, nil
+}
+
+// match reports if name, a slash separated path, matches g.
+func (g glob) match(name string, isDir bool) bool {
+	if g.dirOnly && !isDir {
+		return false
+	}
+	if !g.anchored {
+		ok, _ := path.Match(g.parts[0], path.Base(name))
+		return ok
+	}
+	return matchParts(g.parts, strings.Split(name, "/"))
+}
+
+func matchParts(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchParts(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ignoreRule is a line of an ignore file.
+type ignoreRule struct {
+	glob
+	negate bool // Leading "!", re-includes a path
+}
+
+// parseIgnore parses an ignore file in the .gitignore format.
+func parseIgnore(r io.Reader) ([]ignoreRule, error) {
+	var rules []ignoreRule
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), " \t\r")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		var rule ignoreRule
+		if line[0] == '!' {
+			rule.negate = true
+			line = line[1:]
+		}
+		g, err := compileGlob(line)
+		if err != nil {
+			return nil, err
+		}
+		rule.glob = g
+		rules = append(rules, rule)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
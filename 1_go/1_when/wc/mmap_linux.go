@@ -0,0 +1,68 @@
+//go:build linux
+
+package wc
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"syscall"
+)
+
+// mmapChunk is mapped at once, it bounds the address space used.
+const mmapChunk = 1 << 30
+
+// mmapLineCount counts the lines of f, from its current offset to its end,
+// by mapping it in memory, and moves the offset to the end. It returns false
+// if f is not a regular file or can't be mapped, and should be read instead.
+// The file must not be truncated while it is counted.
+func mmapLineCount(f *os.File) (int, bool, error) {
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return 0, false, nil
+	}
+	off, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, false, nil
+	}
+	size := info.Size()
+	if size-off < mmapMin {
+		return 0, false, nil
+	}
+
+	var (
+		count int
+		last  byte
+	)
+	pageSize := int64(os.Getpagesize())
+	start := off &^ (pageSize - 1) // Offsets must be page aligned
+	for pos := start; pos < size; pos += mmapChunk {
+		data, err := syscall.Mmap(int(f.Fd()), pos, int(min(mmapChunk, size-pos)), syscall.PROT_READ, syscall.MAP_SHARED)
+		if err != nil {
+			if pos == start {
+				return 0, false, nil
+			}
+			return 0, true, err
+		}
+		syscall.Madvise(data, syscall.MADV_SEQUENTIAL)
+
+		region := data
+		if pos == start {
+			region = data[off-start:]
+		}
+		count += bytes.Count(region, []byte{'\n'})
+		last = region[len(region)-1]
+
+		if err := syscall.Munmap(data); err != nil {
+			return 0, true, err
+		}
+	}
+
+	if last != '\n' {
+		count++ // Last line without newline
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		return 0, true, err
+	}
+	return count, true, nil
+}
@@ -0,0 +1,138 @@
+package wc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	markdown "goiface/3_io/3_mem"
+)
+
+// FileCounts are the counts of a file.
+type FileCounts struct {
+	Path     string   `json:"path"`
+	Encoding Encoding `json:"encoding"`
+	Counts
+}
+
+// Group are the total counts of files with the same extension.
+type Group struct {
+	Ext   string `json:"ext"` // "" for files without extension
+	Files int64  `json:"files"`
+	Counts
+}
+
+// Report are the counts of CountFS.
+type Report struct {
+	Files     []FileCounts       `json:"files"`     // In walk order
+	Groups    []Group            `json:"groups"`    // Sorted by extension
+	Encodings map[Encoding]int64 `json:"encodings"` // Number of files
+	Total     Group              `json:"total"`
+}
+
+// report builds a Report.
+type report struct {
+	r      Report
+	groups map[string]*Group
+}
+
+func newReport() *report {
+	return &report{
+		r:      Report{Encodings: make(map[Encoding]int64)},
+		groups: make(map[string]*Group),
+	}
+}
+
+func (r *report) add(name string, enc Encoding, c Counts) {
+	r.r.Files = append(r.r.Files, FileCounts{Path: name, Encoding: enc, Counts: c})
+	r.r.Encodings[enc]++
+
+	ext := path.Ext(name)
+	g, ok := r.groups[ext]
+	if !ok {
+		g = &Group{Ext: ext}
+		r.groups[ext] = g
+	}
+	g.Files++
+	g.Add(c)
+
+	r.r.Total.Files++
+	r.r.Total.Add(c)
+}
+
+func (r *report) done() *Report {
+	r.r.Groups = make([]Group, 0, len(r.groups))
+	for _, g := range r.groups {
+		r.r.Groups = append(r.r.Groups, *g)
+	}
+	sort.Slice(r.r.Groups, func(i, j int) bool {
+		return r.r.Groups[i].Ext < r.r.Groups[j].Ext
+	})
+	return &r.r
+}
+
+// Format is a report output format.
+type Format string
+
+// Report formats.
+const (
+	FormatTable    Format = "table"
+	FormatJSON     Format = "json"
+	FormatMarkdown Format = "markdown"
+)
+
+// Write writes the report to w. The table and markdown formats have a row
+// per extension and a total row, JSON has the counts of every file as well.
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatMarkdown:
+		_, err := io.WriteString(w, markdown.Table(r.header(), r.rows()))
+		return err
+	case FormatTable:
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+		for _, row := range append([][]string{r.header()}, r.rows()...) {
+			for _, cell := range row {
+				fmt.Fprintf(tw, "%s\t", cell)
+			}
+			fmt.Fprintln(tw)
+		}
+		return tw.Flush()
+	}
+
+	return fmt.Errorf("wc: unknown format - %q", format)
+}
+
+func (r *Report) header() []string {
+	return []string{"ext", "files", "lines", "words", "runes", "bytes"}
+}
+
+func (r *Report) rows() [][]string {
+	row := func(name string, g Group) []string {
+		return []string{
+			name,
+			strconv.FormatInt(g.Files, 10),
+			strconv.FormatInt(g.Lines, 10),
+			strconv.FormatInt(g.Words, 10),
+			strconv.FormatInt(g.Runes, 10),
+			strconv.FormatInt(g.Bytes, 10),
+		}
+	}
+
+	rows := make([][]string, 0, len(r.Groups)+1)
+	for _, g := range r.Groups {
+		name := g.Ext
+		if name == "" {
+			name = "(none)"
+		}
+		rows = append(rows, row(name, g))
+	}
+	return append(rows, row("total", r.Total))
+}
@@ -0,0 +1,340 @@
+package wc
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Option configures CountFS.
+type Option func(*options)
+
+type options struct {
+	include     []string
+	exclude     []string
+	ignoreFiles []string
+	archives    bool
+}
+
+// Include counts only the files matching one of patterns. Patterns are
+// matched against the path relative to the root: a pattern with a slash
+// matches the whole path, otherwise it matches the base name, "**" matches
+// any number of directories.
+//
+//	wc.Include("*.go", "docs/**/*.md")
+func Include(patterns ...string) Option {
+	return func(o *options) {
+		o.include = append(o.include, patterns...)
+	}
+}
+
+// Exclude skips the files and directories matching one of patterns, see
+// Include for the pattern syntax.
+func Exclude(patterns ...string) Option {
+	return func(o *options) {
+		o.exclude = append(o.exclude, patterns...)
+	}
+}
+
+// IgnoreFiles reads ignore rules in the .gitignore format from the files
+// called names in every directory, for example ".gitignore". Rules apply to
+// the directory of the ignore file and below.
+func IgnoreFiles(names ...string) Option {
+	return func(o *options) {
+		o.ignoreFiles = append(o.ignoreFiles, names...)
+	}
+}
+
+// Archives counts the members of tar, gzipped tar and zip files instead of
+// the archive files. Members are reported as archive/member.
+func Archives() Option {
+	return func(o *options) {
+		o.archives = true
+	}
+}
+
+// CountFS counts the files under root in fsys, see CountText.
+func CountFS(fsys fs.FS, root string, opts ...Option) (*Report, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	w := walker{
+		fsys:    fsys,
+		root:    root,
+		opts:    o,
+		ignores: make(map[string][]ignoreRule),
+		report:  newReport(),
+	}
+	var err error
+	if w.include, err = compileGlobs(o.include); err != nil {
+		return nil, err
+	}
+	if w.exclude, err = compileGlobs(o.exclude); err != nil {
+		return nil, err
+	}
+
+	if err := fs.WalkDir(fsys, root, w.visit); err != nil {
+		return nil, err
+	}
+	return w.report.done(), nil
+}
+
+func compileGlobs(patterns []string) ([]glob, error) {
+	globs := make([]glob, 0, len(patterns))
+	for _, p := range patterns {
+		g, err := compileGlob(p)
+		if err != nil {
+			return nil, fmt.Errorf("wc: %w", err)
+		}
+		globs = append(globs, g)
+	}
+	return globs, nil
+}
+
+type walker struct {
+	fsys    fs.FS
+	root    string
+	opts    options
+	include []glob
+	exclude []glob
+	ignores map[string][]ignoreRule // Directory (relative to root) -> rules
+	report  *report
+}
+
+func (w *walker) visit(name string, d fs.DirEntry, err error) error {
+	if err != nil {
+		return err
+	}
+
+	rel := w.rel(name, d.IsDir())
+	if name != w.root && w.skip(rel, d.IsDir()) {
+		if d.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	}
+
+	switch {
+	case d.IsDir():
+		return w.loadIgnores(name, rel)
+	case !d.Type().IsRegular():
+		return nil // Symbolic links, devices ...
+	case w.opts.archives && isArchive(name):
+		return w.countArchive(name)
+	case !w.included(rel):
+		return nil
+	}
+
+	file, err := w.fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	c, enc, err := CountText(file)
+	if err != nil {
+		return fmt.Errorf("%q: %w", name, err)
+	}
+	w.report.add(name, enc, c)
+	return nil
+}
+
+// rel returns name relative to the root, or its base name if the root is a
+// file.
+func (w *walker) rel(name string, isDir bool) string {
+	if name == w.root {
+		if isDir {
+			return "."
+		}
+		return path.Base(name)
+	}
+	if w.root == "." {
+		return name
+	}
+	return strings.TrimPrefix(name, w.root+"/")
+}
+
+// skip reports if the path rel is excluded or ignored.
+func (w *walker) skip(rel string, isDir bool) bool {
+	for _, g := range w.exclude {
+		if g.match(rel, isDir) {
+			return true
+		}
+	}
+	return w.ignored(rel, isDir)
+}
+
+// ignored reports if rel is ignored by the ignore files of its parent
+// directories. The last matching rule wins, and rules in deeper directories
+// come last.
+func (w *walker) ignored(rel string, isDir bool) bool {
+	ignored := false
+	check := func(dir, sub string) {
+		for _, r := range w.ignores[dir] {
+			if r.match(sub, isDir) {
+				ignored = !r.negate
+			}
+		}
+	}
+
+	check(".", rel)
+	for i := range rel {
+		if rel[i] == '/' {
+			check(rel[:i], rel[i+1:])
+		}
+	}
+	return ignored
+}
+
+func (w *walker) included(rel string) bool {
+	if len(w.include) == 0 {
+		return true
+	}
+	for _, g := range w.include {
+		if g.match(rel, false) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *walker) loadIgnores(dir, rel string) error {
+	for _, name := range w.opts.ignoreFiles {
+		data, err := fs.ReadFile(w.fsys, path.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		rules, err := parseIgnore(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%q: %w", path.Join(dir, name), err)
+		}
+		w.ignores[rel] = append(w.ignores[rel], rules...)
+	}
+	return nil
+}
+
+func isArchive(name string) bool {
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// countArchive counts the regular files in the archive name. Patterns and
+// ignore rules apply to the archive/member paths.
+func (w *walker) countArchive(name string) error {
+	file, err := w.fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if strings.HasSuffix(name, ".zip") {
+		err = w.countZip(name, file)
+	} else {
+		err = w.countTar(name, file)
+	}
+	if err != nil {
+		return fmt.Errorf("%q: %w", name, err)
+	}
+	return nil
+}
+
+func (w *walker) countMember(archive, member string, r io.Reader) error {
+	name := path.Join(archive, member)
+	rel := w.rel(name, false)
+	if w.skip(rel, false) || !w.included(rel) {
+		return nil
+	}
+
+	c, enc, err := CountText(r)
+	if err != nil {
+		return fmt.Errorf("%q: %w", member, err)
+	}
+	w.report.add(name, enc, c)
+	return nil
+}
+
+func (w *walker) countTar(name string, r io.Reader) error {
+	if !strings.HasSuffix(name, ".tar") {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := w.countMember(name, hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *walker) countZip(name string, file fs.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// zip needs random access, read the archive in memory if the file
+	// doesn't support it
+	ra, ok := file.(io.ReaderAt)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		ra = bytes.NewReader(data)
+	}
+
+	zr, err := zip.NewReader(ra, info.Size())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = w.countMember(name, f.Name, r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
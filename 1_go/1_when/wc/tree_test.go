@@ -0,0 +1,286 @@
+package wc
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCount(t *testing.T) {
+	testCases := []struct {
+		data string
+		want Counts
+	}{
+		{"", Counts{}},
+		{"one", Counts{Lines: 1, Words: 1, Runes: 3, Bytes: 3}},
+		{"one two\n", Counts{Lines: 1, Words: 2, Runes: 8, Bytes: 8}},
+		{"\n\n", Counts{Lines: 2, Words: 0, Runes: 2, Bytes: 2}},
+		{testData, Counts{Lines: 5, Words: 19, Runes: 89, Bytes: 89}},
+		{" a\tb\r\nc ", Counts{Lines: 2, Words: 3, Runes: 8, Bytes: 8}},
+	}
+
+	for _, tc := range testCases {
+		c, err := Count(strings.NewReader(tc.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != tc.want {
+			t.Errorf("%q: got %+v, want %+v", tc.data, c, tc.want)
+		}
+	}
+}
+
+func TestGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		name    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.go", "a.go", false, true},
+		{"*.go", "x/y/a.go", false, true},
+		{"*.go", "a.golden", false, false},
+		{"/a.go", "a.go", false, true},
+		{"/a.go", "x/a.go", false, false},
+		{"x/*.go", "x/a.go", false, true},
+		{"x/*.go", "x/y/a.go", false, false},
+		{"x/**/*.go", "x/a.go", false, true},
+		{"x/**/*.go", "x/y/z/a.go", false, true},
+		{"**/testdata", "a/b/testdata", true, true},
+		{"build/", "build", true, true},
+		{"build/", "build", false, false},
+		{"x/**", "x/y/z", false, true},
+	}
+
+	for _, tc := range testCases {
+		g, err := compileGlob(tc.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := g.match(tc.name, tc.isDir); got != tc.want {
+			t.Errorf("%q %q: got %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+
+	for _, pattern := range []string{"", "/", "[a"} {
+		if _, err := compileGlob(pattern); err == nil {
+			t.Errorf("%q: no error", pattern)
+		}
+	}
+}
+
+func tarData(t *testing.T, compress bool, files map[string]string) []byte {
+	var buf bytes.Buffer
+	var tw *tar.Writer
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(zw)
+	} else {
+		tw = tar.NewWriter(&buf)
+	}
+
+	tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755})
+	for name, data := range files {
+		hdr := tar.Header{Name: name, Size: int64(len(data)), Mode: 0644}
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(data))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if compress {
+		zw.Close()
+	}
+	return buf.Bytes()
+}
+
+func zipData(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func paths(r *Report) []string {
+	var names []string
+	for _, f := range r.Files {
+		names = append(names, f.Path)
+	}
+	return names
+}
+
+func TestCountFS(t *testing.T) {
+	file := func(data string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(data)}
+	}
+
+	fsys := fstest.MapFS{
+		".gitignore":           file("*.log\nbuild/\n!keep.log\n"),
+		"main.go":              file("package main\n"),
+		"debug.log":            file("ignored\n"),
+		"keep.log":             file("kept\n"),
+		"build/out.txt":        file("ignored\n"),
+		"docs/README.md":       file("# Title\n\nSome text\n"),
+		"docs/.gitignore":      file("/draft.md\n!debug.log\n"),
+		"docs/draft.md":        file("ignored\n"),
+		"docs/debug.log":       file("re-included\n"),
+		"docs/api/draft.md":    file("not anchored here\n"),
+		"testdata/data.txt":    file("excluded\n"),
+		"Makefile":             file("all:\n\tgo build\n"),
+		"archives/src.tar":     {Data: tarData(t, false, map[string]string{"dir/a.go": "package a\n"})},
+		"archives/src.tar.gz":  {Data: tarData(t, true, map[string]string{"b.go": "package b\n", "c.log": "x\n"})},
+		"archives/src.zip":     {Data: zipData(t, map[string]string{"z/z.go": "package z\n\nvar z = 1\n"})},
+		"archives/testdata.go": file("package archives\n"),
+	}
+
+	r, err := CountFS(fsys, ".", IgnoreFiles(".gitignore"), Exclude("testdata", ".gitignore"), Archives())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"Makefile",
+		"archives/src.tar/dir/a.go",
+		"archives/src.tar.gz/b.go",
+		"archives/src.zip/z/z.go",
+		"archives/testdata.go",
+		"docs/README.md",
+		"docs/api/draft.md",
+		"docs/debug.log",
+		"keep.log",
+		"main.go",
+	}
+	if got := paths(r); !reflect.DeepEqual(got, want) {
+		t.Fatalf("files: got %v, want %v", got, want)
+	}
+
+	wantGroups := []Group{
+		{Ext: "", Files: 1, Counts: Counts{Lines: 2, Words: 3, Runes: 15, Bytes: 15}},
+		{Ext: ".go", Files: 5, Counts: Counts{Lines: 7, Words: 14, Runes: 71, Bytes: 71}},
+		{Ext: ".log", Files: 2, Counts: Counts{Lines: 2, Words: 2, Runes: 17, Bytes: 17}},
+		{Ext: ".md", Files: 2, Counts: Counts{Lines: 4, Words: 7, Runes: 37, Bytes: 37}},
+	}
+	if !reflect.DeepEqual(r.Groups, wantGroups) {
+		t.Fatalf("groups: got %+v, want %+v", r.Groups, wantGroups)
+	}
+	if r.Total.Files != 10 || r.Total.Lines != 15 {
+		t.Fatalf("total: %+v", r.Total)
+	}
+
+	// Include and sub directory root
+	r, err = CountFS(fsys, "docs", Include("*.md"), IgnoreFiles(".gitignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"docs/README.md", "docs/api/draft.md"}
+	if got := paths(r); !reflect.DeepEqual(got, want) {
+		t.Fatalf("files: got %v, want %v", got, want)
+	}
+
+	// Archives are regular files without the option
+	r, err = CountFS(fsys, "archives", Include("*.tar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"archives/src.tar"}
+	if got := paths(r); !reflect.DeepEqual(got, want) {
+		t.Fatalf("files: got %v, want %v", got, want)
+	}
+}
+
+func TestCountFS_Errors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":      {Data: []byte("a\n")},
+		"bad.zip":    {Data: []byte("not a zip")},
+		".gitignore": {Data: []byte("[a\n")},
+	}
+
+	if _, err := CountFS(fsys, ".", Include("[")); err == nil {
+		t.Error("bad include pattern: no error")
+	}
+	if _, err := CountFS(fsys, ".", IgnoreFiles(".gitignore")); err == nil {
+		t.Error("bad ignore file: no error")
+	}
+	if _, err := CountFS(fsys, ".", Archives()); err == nil || !strings.Contains(err.Error(), "bad.zip") {
+		t.Errorf("bad archive: %v", err)
+	}
+	if _, err := CountFS(fsys, "missing"); err == nil {
+		t.Error("missing root: no error")
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.go":  {Data: []byte("package a\n")},
+		"b.go":  {Data: []byte("package b\n\nvar b int\n")},
+		"a.txt": {Data: []byte("a\n")},
+	}
+	r, err := CountFS(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf, FormatTable); err != nil {
+		t.Fatal(err)
+	}
+	table := `    ext  files  lines  words  runes  bytes
+    .go      2      4      7     31     31
+   .txt      1      1      1      2      2
+  total      3      5      8     33     33
+`
+	if buf.String() != table {
+		t.Errorf("table:\n%s\nwant:\n%s", buf.String(), table)
+	}
+
+	buf.Reset()
+	if err := r.Write(&buf, FormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	md := `| ext | files | lines | words | runes | bytes |
+| --- | --- | --- | --- | --- | --- |
+| .go | 2 | 4 | 7 | 31 | 31 |
+| .txt | 1 | 1 | 1 | 2 | 2 |
+| total | 3 | 5 | 8 | 33 | 33 |
+`
+	if buf.String() != md {
+		t.Errorf("markdown:\n%s\nwant:\n%s", buf.String(), md)
+	}
+
+	buf.Reset()
+	if err := r.Write(&buf, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var r2 Report
+	if err := json.Unmarshal(buf.Bytes(), &r2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*r, r2) {
+		t.Errorf("json: got %+v, want %+v", r2, *r)
+	}
+	if !strings.Contains(buf.String(), `"path": "b.go"`) {
+		t.Errorf("json: missing file counts\n%s", buf.String())
+	}
+
+	if err := r.Write(&buf, "yaml"); err == nil {
+		t.Error("unknown format: no error")
+	}
+}
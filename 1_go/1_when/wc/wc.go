@@ -1,21 +1,43 @@
 package wc
 
 import (
-	"bufio"
+	"bytes"
 	"io"
+	"os"
 )
 
+// mmapMin is the minimal size of mapped files, smaller files are faster to
+// read.
+const mmapMin = 1 << 20
+
 // LineCount return how many lines in r.
+// On Linux, large regular files are memory mapped instead of read.
 func LineCount(r io.Reader) (int, error) {
-	s := bufio.NewScanner(r)
-	count := 0
-	for s.Scan() {
-		count++
+	if f, ok := r.(*os.File); ok {
+		if count, ok, err := mmapLineCount(f); ok {
+			return count, err
+		}
 	}
 
-	if err := s.Err(); err != nil {
-		return 0, err
+	// Count newlines like mmapLineCount, lines have no maximal length
+	buf := make([]byte, 64<<10)
+	count, last := 0, byte('\n')
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			count += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
 	}
 
+	if last != '\n' {
+		count++ // Last line without newline
+	}
 	return count, nil
 }
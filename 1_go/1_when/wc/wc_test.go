@@ -1,6 +1,10 @@
 package wc
 
 import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"testing/iotest"
@@ -20,3 +24,125 @@ func TestLineCountError(t *testing.T) {
 		t.Fatal("no error on read")
 	}
 }
+
+// writeLines writes a file of size bytes or more, with lines of up to 100
+// bytes, and returns its name and number of lines.
+func writeLines(tb testing.TB, size int64) (string, int) {
+	fileName := filepath.Join(tb.TempDir(), "lines.txt")
+	file, err := os.Create(fileName)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	count := 0
+	for n := int64(0); n < size; count++ {
+		line := strings.Repeat("x", count%100) + "\n"
+		w.WriteString(line)
+		n += int64(len(line))
+	}
+	if err := w.Flush(); err != nil {
+		tb.Fatal(err)
+	}
+	return fileName, count
+}
+
+func TestLineCount_File(t *testing.T) {
+	fileName, want := writeLines(t, 3*mmapMin+123)
+	file, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	count, err := LineCount(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != want {
+		t.Fatalf("got %d, want %d", count, want)
+	}
+
+	// At the end, like after reading
+	if count, err := LineCount(file); err != nil || count != 0 {
+		t.Fatalf("at end: got %d, %v", count, err)
+	}
+
+	// From the current offset, not page aligned. Lines 0 to 12 are 91 bytes.
+	if _, err := file.Seek(91, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	count, err = LineCount(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != want-13 {
+		t.Fatalf("from offset: got %d, want %d", count, want-13)
+	}
+
+	// No final newline
+	f, err := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("last")
+	f.Close()
+	file.Seek(0, io.SeekStart)
+	if count, err := LineCount(file); err != nil || count != want+1 {
+		t.Fatalf("no final newline: got %d, %v, want %d", count, err, want+1)
+	}
+}
+
+func TestLineCount_Pipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		w.WriteString(testData)
+		w.Close()
+	}()
+	defer r.Close()
+
+	count, err := LineCount(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 5 {
+		t.Fatalf("got %d, want 5", count)
+	}
+}
+
+func TestLineCount_LongLines(t *testing.T) {
+	// Lines over bufio.MaxScanTokenSize, in a file large enough to be mapped
+	line := strings.Repeat("x", 3*bufio.MaxScanTokenSize) + "\n"
+	data := strings.Repeat(line, mmapMin/len(line)+2)
+	want := strings.Count(data, "\n")
+
+	fileName := filepath.Join(t.TempDir(), "long.txt")
+	if err := os.WriteFile(fileName, []byte(data), 0666); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	readers := map[string]io.Reader{
+		"file":   file,
+		"reader": strings.NewReader(data),
+		"short":  strings.NewReader(line + "x"),
+	}
+	wants := map[string]int{"file": want, "reader": want, "short": 2}
+	for name, r := range readers {
+		count, err := LineCount(r)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if count != wants[name] {
+			t.Fatalf("%s: got %d, want %d", name, count, wants[name])
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package sort
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+)
+
+var benchSizes = []int{1 << 10, 1 << 16, 1 << 20}
+
+func BenchmarkRadix(b *testing.B) {
+	for _, n := range benchSizes {
+		keys := randomKeys[uint](n, 1<<63)
+		buf := make([]uint, n)
+
+		b.Run(fmt.Sprintf("Radix/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				copy(buf, keys)
+				Radix(buf)
+			}
+		})
+
+		b.Run(fmt.Sprintf("slices.Sort/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				copy(buf, keys)
+				slices.Sort(buf)
+			}
+		})
+	}
+}
+
+func BenchmarkRadixFunc(b *testing.B) {
+	for _, n := range benchSizes {
+		keys := randomKeys[uint](n, 1<<63)
+		records := make([]record, n)
+		for i, k := range keys {
+			records[i] = record{Key: k, Seq: i}
+		}
+		buf := make([]record, n)
+
+		b.Run(fmt.Sprintf("RadixFunc/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				copy(buf, records)
+				RadixFunc(buf, func(r record) uint { return r.Key })
+			}
+		})
+
+		b.Run(fmt.Sprintf("slices.SortStableFunc/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				copy(buf, records)
+				slices.SortStableFunc(buf, func(a, b record) int {
+					switch {
+					case a.Key < b.Key:
+						return -1
+					case a.Key > b.Key:
+						return 1
+					}
+					return 0
+				})
+			}
+		})
+
+		b.Run(fmt.Sprintf("sort.SliceStable/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				copy(buf, records)
+				sort.SliceStable(buf, func(i, j int) bool { return buf[i].Key < buf[j].Key })
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package sort_test
+
+import (
+	"fmt"
+	"slices"
+
+	"goiface/2_design/2_sort"
+)
+
+func ExampleNatural() {
+	versions := []string{"1.10", "1.9", "1.9.1", "1.2"}
+	slices.SortFunc(versions, sort.Natural)
+	fmt.Println(versions)
+
+	// Output:
+	// [1.2 1.9 1.9.1 1.10]
+}
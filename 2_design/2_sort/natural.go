@@ -0,0 +1,80 @@
+package sort
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Natural compares a and b in natural order, digit runs are compared
+// numerically. "log-9.txt" < "log-10.txt" and "1.9" < "1.10".
+// It returns -1, 0 or +1 like strings.Compare, and can be used with
+// slices.SortFunc.
+func Natural(a, b string) int {
+	return natural(a, b, false)
+}
+
+// NaturalFold is like Natural but ignores case.
+func NaturalFold(a, b string) int {
+	return natural(a, b, true)
+}
+
+func natural(a, b string, fold bool) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			ea, eb := digitsEnd(a, i), digitsEnd(b, j)
+			if c := compareDigits(a[i:ea], b[j:eb]); c != 0 {
+				return c
+			}
+			i, j = ea, eb
+			continue
+		}
+
+		ra, na := utf8.DecodeRuneInString(a[i:])
+		rb, nb := utf8.DecodeRuneInString(b[j:])
+		if fold {
+			ra, rb = unicode.ToLower(ra), unicode.ToLower(rb)
+		}
+		switch {
+		case ra < rb:
+			return -1
+		case ra > rb:
+			return 1
+		}
+		i, j = i+na, j+nb
+	}
+
+	switch {
+	case len(a)-i < len(b)-j:
+		return -1
+	case len(a)-i > len(b)-j:
+		return 1
+	}
+
+	// Equal in natural order (e.g. "a01" and "a1"), keep the order total
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func digitsEnd(s string, i int) int {
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+// compareDigits compares two digit runs numerically, without overflow.
+func compareDigits(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,50 @@
+package sort
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNatural(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		want int
+	}{
+		{"log-09.txt", "log-10.txt", -1},
+		{"log-99.txt", "log-100.txt", -1},
+		{"log-100.txt", "log-99.txt", 1},
+		{"1.10", "1.9", 1},
+		{"1.2.10", "1.2.9", 1},
+		{"v1.9.0", "v1.10.0", -1},
+		{"a", "a", 0},
+		{"a", "ab", -1},
+		{"a2", "a10b", -1},
+		{"x18446744073709551616", "x18446744073709551615", 1}, // overflows uint64
+		{"a01", "a1", -1},                                     // numerically equal, byte order
+		{"B", "a", -1},
+		{"", "0", -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.a+"/"+tc.b, func(t *testing.T) {
+			require.Equal(t, tc.want, Natural(tc.a, tc.b))
+			require.Equal(t, -tc.want, Natural(tc.b, tc.a))
+		})
+	}
+}
+
+func TestNaturalFold(t *testing.T) {
+	require.Equal(t, 1, NaturalFold("B", "a"))
+	require.Equal(t, -1, NaturalFold("Log-9", "log-10"))
+	require.Equal(t, -1, NaturalFold("ÉTÉ-2", "été-10"))
+	require.NotZero(t, NaturalFold("A", "a"), "total order")
+}
+
+func TestNatural_Sort(t *testing.T) {
+	names := []string{"log-100.txt", "log-02.txt", "log-10.txt", "log-99.txt", "log-01.txt"}
+	slices.SortFunc(names, Natural)
+	want := []string{"log-01.txt", "log-02.txt", "log-10.txt", "log-99.txt", "log-100.txt"}
+	require.Equal(t, want, names)
+}
@@ -0,0 +1,117 @@
+package sort
+
+import (
+	"unsafe"
+)
+
+// Unsigned is the set of unsigned integer types.
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Small slices are insertion sorted.
+const radixCutoff = 64
+
+// Radix sorts keys in increasing order using an LSD radix sort, one byte at a
+// time. It allocates a buffer of len(keys).
+func Radix[K Unsigned](keys []K) {
+	if len(keys) < radixCutoff {
+		insertionSort(keys, func(k K) K { return k })
+		return
+	}
+
+	buf := make([]K, len(keys))
+	src, dst := keys, buf
+	for shift := uint(0); shift < bits[K](); shift += 8 {
+		var counts [256]int
+		for _, k := range src {
+			counts[byte(k>>shift)]++
+		}
+		if !prefixSum(&counts, len(src)) {
+			continue // all keys have the same byte
+		}
+
+		for _, k := range src {
+			b := byte(k >> shift)
+			dst[counts[b]] = k
+			counts[b]++
+		}
+		src, dst = dst, src
+	}
+
+	if &src[0] != &keys[0] {
+		copy(keys, src)
+	}
+}
+
+// RadixFunc sorts s by the keys returned by key, it is stable. Keys are
+// computed once, it allocates buffers for len(s) keys and elements.
+//
+//	sort.RadixFunc(records, func(r Record) uint { return r.Key })
+func RadixFunc[T any, K Unsigned](s []T, key func(T) K) {
+	if len(s) < radixCutoff {
+		insertionSort(s, key)
+		return
+	}
+
+	keys := make([]K, len(s))
+	for i, v := range s {
+		keys[i] = key(v)
+	}
+	keyBuf := make([]K, len(s))
+	buf := make([]T, len(s))
+
+	src, dst := s, buf
+	srcKeys, dstKeys := keys, keyBuf
+	for shift := uint(0); shift < bits[K](); shift += 8 {
+		var counts [256]int
+		for _, k := range srcKeys {
+			counts[byte(k>>shift)]++
+		}
+		if !prefixSum(&counts, len(srcKeys)) {
+			continue
+		}
+
+		for i, k := range srcKeys {
+			b := byte(k >> shift)
+			dst[counts[b]] = src[i]
+			dstKeys[counts[b]] = k
+			counts[b]++
+		}
+		src, dst = dst, src
+		srcKeys, dstKeys = dstKeys, srcKeys
+	}
+
+	if &src[0] != &s[0] {
+		copy(s, src)
+	}
+}
+
+// bits returns the size of K in bits.
+func bits[K Unsigned]() uint {
+	var k K
+	return uint(unsafe.Sizeof(k)) * 8
+}
+
+// prefixSum turns counts into start offsets, it returns false if a single
+// bucket has all n elements (the pass can be skipped).
+func prefixSum(counts *[256]int, n int) bool {
+	offset := 0
+	for i, c := range counts {
+		if c == n {
+			return false
+		}
+		counts[i] = offset
+		offset += c
+	}
+	return true
+}
+
+// insertionSort is a stable sort for small slices.
+func insertionSort[T any, K Unsigned](s []T, key func(T) K) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && key(s[j]) < key(s[j-1]); j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
+	}
+}
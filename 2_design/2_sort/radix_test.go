@@ -0,0 +1,63 @@
+package sort
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	Key uint
+	Seq int
+}
+
+func randomKeys[K Unsigned](n int, max uint64) []K {
+	rnd := rand.New(rand.NewSource(7))
+	keys := make([]K, n)
+	for i := range keys {
+		keys[i] = K(rnd.Uint64() % max)
+	}
+	return keys
+}
+
+func testRadix[K Unsigned](t *testing.T, max uint64) {
+	for _, n := range []int{0, 1, 10, radixCutoff, 1000, 10_000} {
+		keys := randomKeys[K](n, max)
+		want := slices.Clone(keys)
+		slices.Sort(want)
+
+		Radix(keys)
+		require.Equal(t, want, keys, "n=%d", n)
+	}
+}
+
+type level uint16
+
+func TestRadix(t *testing.T) {
+	t.Run("uint", func(t *testing.T) { testRadix[uint](t, 1<<63) })
+	t.Run("uint8", func(t *testing.T) { testRadix[uint8](t, 1<<8) })
+	t.Run("uint16", func(t *testing.T) { testRadix[uint16](t, 1<<16) })
+	t.Run("uint32", func(t *testing.T) { testRadix[uint32](t, 1<<32) })
+	t.Run("uint64", func(t *testing.T) { testRadix[uint64](t, 1<<63) })
+	t.Run("small", func(t *testing.T) { testRadix[uint64](t, 100) }) // skipped passes
+	t.Run("level", func(t *testing.T) { testRadix[level](t, 1<<16) })
+}
+
+func TestRadixFunc(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 10_000} {
+		keys := randomKeys[uint](n, 50) // many duplicates
+		records := make([]record, n)
+		for i, k := range keys {
+			records[i] = record{Key: k, Seq: i}
+		}
+		want := slices.Clone(records)
+		slices.SortStableFunc(want, func(a, b record) int {
+			return int(a.Key) - int(b.Key)
+		})
+
+		RadixFunc(records, func(r record) uint { return r.Key })
+		require.Equal(t, want, records, "n=%d", n)
+	}
+}
@@ -3,14 +3,43 @@ package open
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 // OpenURI opens a URI.
 // Supported schemes are: file, http & https.
 func OpenURI(uri string) (io.ReadCloser, error) {
+	return openURI(uri, func(path string) (io.ReadCloser, error) {
+		if path == "" {
+			return nil, fmt.Errorf("%q: empty file path", uri)
+		}
+		// file:///C:/go.txt has the path /C:/go.txt
+		if len(path) > 1 && filepath.VolumeName(path[1:]) != "" {
+			path = path[1:]
+		}
+		file, err := os.Open(filepath.Clean(filepath.FromSlash(path)))
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	})
+}
+
+// OpenURIFS is like OpenURI but opens file URIs in fsys, file paths are
+// relative to the root of fsys. Paths must be valid (see fs.ValidPath) once
+// the leading "/" is removed, file:///a/../b.txt is an error.
+func OpenURIFS(fsys fs.FS, uri string) (io.ReadCloser, error) {
+	return openURI(uri, func(path string) (io.ReadCloser, error) {
+		return fsys.Open(strings.TrimPrefix(path, "/"))
+	})
+}
+
+func openURI(uri string, openFile func(path string) (io.ReadCloser, error)) (io.ReadCloser, error) {
 	u, err := url.Parse(uri)
 	if err != nil {
 		return nil, err
@@ -28,11 +57,11 @@ func OpenURI(uri string) (io.ReadCloser, error) {
 		}
 		return resp.Body, nil
 	case "file":
-		file, err := os.Open(u.Path)
-		if err != nil {
-			return nil, err
+		// file://go.txt has the host "go.txt" and an empty path
+		if u.Host != "" && u.Host != "localhost" {
+			return nil, fmt.Errorf("%q: unsupported file host - %s", uri, u.Host)
 		}
-		return file, nil
+		return openFile(u.Path)
 	}
 
 	return nil, fmt.Errorf("unknown scheme: %s", u.Scheme)
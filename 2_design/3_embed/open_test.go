@@ -0,0 +1,82 @@
+package open
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goiface/3_io/faultio"
+	"goiface/3_io/wfs"
+)
+
+func TestOpenURIFS(t *testing.T) {
+	fsys := wfs.NewMemFS()
+	require.NoError(t, fsys.MkdirAll("data", 0700))
+	require.NoError(t, wfs.WriteFile(fsys, "data/go.txt", []byte("Go"), 0600))
+
+	file, err := OpenURIFS(fsys, "file:///data/go.txt")
+	require.NoError(t, err)
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.Equal(t, "Go", string(data))
+
+	_, err = OpenURIFS(fsys, "file:///data/rust.txt")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	fsys.Inject(wfs.Fault{Op: wfs.OpOpen})
+	_, err = OpenURIFS(fsys, "file:///data/go.txt")
+	require.ErrorIs(t, err, faultio.ErrInjected)
+}
+
+func TestOpenURI_File(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "go.txt")
+	require.NoError(t, os.WriteFile(fileName, []byte("Go"), 0600))
+
+	file, err := OpenURI("file://" + filepath.ToSlash(fileName))
+	require.NoError(t, err)
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.Equal(t, "Go", string(data))
+}
+
+func TestOpenURI_Paths(t *testing.T) {
+	dir := filepath.ToSlash(t.TempDir())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.txt"), []byte("Go"), 0600))
+
+	for _, path := range []string{"/sub/../go.txt", "//go.txt", "/./go.txt"} {
+		file, err := OpenURI("file://" + dir + path)
+		require.NoError(t, err, path)
+		file.Close()
+	}
+
+	_, err := OpenURI("file://" + dir + "/rust.txt")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	file, err := OpenURI("file://localhost" + dir + "/go.txt")
+	require.NoError(t, err)
+	file.Close()
+
+	// Host or empty path, not the current directory
+	for _, uri := range []string{"file://go.txt", "file://host" + dir + "/go.txt", "file:", "file://"} {
+		_, err := OpenURI(uri)
+		require.Error(t, err, uri)
+		_, err = OpenURIFS(wfs.NewMemFS(), uri)
+		require.Error(t, err, uri)
+	}
+
+	// Paths in fsys must be valid
+	fsys := wfs.NewMemFS()
+	require.NoError(t, wfs.WriteFile(fsys, "go.txt", []byte("Go"), 0600))
+	for _, path := range []string{"/sub/../go.txt", "//go.txt", "/./go.txt"} {
+		_, err := OpenURIFS(fsys, "file://"+path)
+		require.ErrorIs(t, err, fs.ErrInvalid, path)
+	}
+}
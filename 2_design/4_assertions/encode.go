@@ -6,6 +6,8 @@ import (
 	"io"
 	"os"
 	"time"
+
+	"goiface/2_design/clock"
 )
 
 type Event struct {
@@ -18,24 +20,32 @@ type syncer interface {
 }
 
 type Encoder struct {
-	w io.Writer
-	s syncer
+	w     io.Writer
+	s     syncer
+	clock clock.Clock
 }
 
 type nosincer struct {
 	w io.Writer
 }
 
-func NewEncoder(w io.Writer) *Encoder {
+// NewEncoder returns an encoder writing to w, clk (nil is the system clock) is
+// used to time events.
+func NewEncoder(w io.Writer, clk clock.Clock) *Encoder {
 	if s, ok := w.(syncer); ok {
-		return &Encoder{w: w, s: s}
+		return &Encoder{w: w, s: s, clock: clock.Or(clk)}
 	}
 
-	e := Encoder{w: w}
+	e := Encoder{w: w, clock: clock.Or(clk)}
 	return &e
 }
 
+// Encode writes evt as JSON, events without time are set to the current time.
 func (e *Encoder) Encode(evt Event) error {
+	if evt.Time.IsZero() {
+		evt.Time = e.clock.Now().UTC()
+	}
+
 	data, err := json.Marshal(evt)
 	if err != nil {
 		return err
@@ -58,9 +68,8 @@ func (e *Encoder) Encode(evt Event) error {
 }
 
 func main() {
-	enc := NewEncoder(os.Stdout)
+	enc := NewEncoder(os.Stdout, clock.Real{})
 	evt := Event{
-		Time:    time.Now().UTC(),
 		Message: "elliot login",
 	}
 	enc.Encode(evt)
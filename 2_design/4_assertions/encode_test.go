@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goiface/2_design/clock"
+	"goiface/3_io/faultio"
+)
+
+var testEvent = Event{
+	Time:    time.Date(2024, 2, 11, 20, 27, 31, 0, time.UTC),
+	Message: "elliot login",
+}
+
+func TestEncoder_PartialWrite(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(faultio.NewWriter(&buf, faultio.ShortWrites(10)), nil)
+
+	err := enc.Encode(testEvent)
+	require.ErrorContains(t, err, "partial write (10 out of")
+}
+
+func TestEncoder_WriteError(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(faultio.NewWriter(&buf, faultio.FailAfter(0, nil)), nil)
+
+	err := enc.Encode(testEvent)
+	require.ErrorIs(t, err, faultio.ErrInjected)
+}
+
+func TestEncoder_Sync(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(faultio.NewWriter(&buf, faultio.FailSync(nil)), nil)
+	require.NotNil(t, enc.s, "syncer not detected")
+
+	require.NoError(t, enc.Encode(testEvent)) // Sync errors are ignored
+	require.Contains(t, buf.String(), `"message":"elliot login"`)
+}
+
+func TestEncoder_Clock(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, clock.NewFake(testEvent.Time))
+
+	require.NoError(t, enc.Encode(Event{Message: "elliot login"}))
+	require.Equal(t, `{"time":"2024-02-11T20:27:31Z","message":"elliot login"}`, buf.String())
+}
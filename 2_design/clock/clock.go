@@ -0,0 +1,249 @@
+// Package clock provides an injectable time source, with a fake implementation
+// for tests.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a time source.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is like time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is like time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// Real is the system clock.
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+func (Real) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (Real) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (Real) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// Or returns c, or the system clock if c is nil.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real{}
+	}
+	return c
+}
+
+// Fake is a clock that moves only when advanced, timers and tickers fire
+// during Advance. It is safe for concurrent use.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake returns a fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now implements Clock
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// After implements Clock
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer implements Clock
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.add(d, 0)
+}
+
+// NewTicker implements Clock
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.add(d, d)}
+}
+
+func (f *Fake) add(d, period time.Duration) *fakeTimer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := fakeTimer{
+		fake:   f,
+		at:     f.now.Add(d),
+		period: period,
+		ch:     make(chan time.Time, 1),
+	}
+	f.timers = append(f.timers, &t)
+	f.fire() // d <= 0
+	return &t
+}
+
+// Advance moves the clock forward by d, firing timers and tickers in order.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	end := f.now.Add(d)
+	for {
+		t := f.next()
+		if t == nil || t.at.After(end) {
+			break
+		}
+		f.now = t.at
+		f.fire()
+	}
+	f.now = end
+}
+
+// Set sets the clock time, firing due timers and tickers in order.
+func (f *Fake) Set(t time.Time) {
+	f.Advance(t.Sub(f.Now()))
+}
+
+// Waiters returns the number of active timers and tickers.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.timers)
+}
+
+// next returns the first timer to fire, f.mu must be held.
+func (f *Fake) next() *fakeTimer {
+	var first *fakeTimer
+	for _, t := range f.timers {
+		if first == nil || t.at.Before(first.at) {
+			first = t
+		}
+	}
+	return first
+}
+
+// fire fires the due timers, f.mu must be held.
+func (f *Fake) fire() {
+	active := f.timers[:0]
+	for _, t := range f.timers {
+		if t.at.After(f.now) {
+			active = append(active, t)
+			continue
+		}
+
+		// Like time.Ticker, drop ticks for slow receivers
+		select {
+		case t.ch <- f.now:
+		default:
+		}
+
+		if t.period > 0 {
+			t.at = t.at.Add(t.period)
+			active = append(active, t)
+		}
+	}
+	f.timers = active
+}
+
+// remove removes t from the active timers, it returns true if t was active.
+// f.mu must be held.
+func (f *Fake) remove(t *fakeTimer) bool {
+	for i, other := range f.timers {
+		if other == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	fake   *Fake
+	at     time.Time
+	period time.Duration // 0 for timers
+	ch     chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Stop implements Timer
+func (t *fakeTimer) Stop() bool {
+	t.fake.mu.Lock()
+	defer t.fake.mu.Unlock()
+
+	return t.fake.remove(t)
+}
+
+// Reset implements Timer
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.fake.mu.Lock()
+	defer t.fake.mu.Unlock()
+
+	active := t.fake.remove(t)
+	t.at = t.fake.now.Add(d)
+	if t.period > 0 {
+		t.period = d
+	}
+	t.fake.timers = append(t.fake.timers, t)
+	t.fake.fire()
+	return active
+}
+
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
+
+func (t fakeTicker) Reset(d time.Duration) {
+	t.fakeTimer.Reset(d)
+}
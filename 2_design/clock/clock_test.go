@@ -0,0 +1,94 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 2, 11, 20, 27, 31, 0, time.UTC)
+
+func fired(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-ch:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFake_Timer(t *testing.T) {
+	c := NewFake(start)
+	timer := c.NewTimer(time.Second)
+	after := c.After(2 * time.Second)
+	require.Equal(t, 2, c.Waiters())
+
+	c.Advance(999 * time.Millisecond)
+	_, ok := fired(timer.C())
+	require.False(t, ok)
+
+	c.Advance(time.Millisecond)
+	at, ok := fired(timer.C())
+	require.True(t, ok)
+	require.Equal(t, start.Add(time.Second), at)
+	require.False(t, timer.Stop())
+
+	require.False(t, timer.Reset(time.Minute))
+	c.Advance(time.Hour)
+	at, ok = fired(after)
+	require.True(t, ok)
+	require.Equal(t, start.Add(2*time.Second), at)
+	at, ok = fired(timer.C())
+	require.True(t, ok)
+	require.Equal(t, start.Add(time.Second+time.Minute), at)
+
+	require.Equal(t, start.Add(time.Hour+time.Second), c.Now())
+	require.Equal(t, 0, c.Waiters())
+}
+
+func TestFake_Stop(t *testing.T) {
+	c := NewFake(start)
+	timer := c.NewTimer(time.Second)
+	require.True(t, timer.Stop())
+	c.Advance(time.Minute)
+	_, ok := fired(timer.C())
+	require.False(t, ok)
+}
+
+func TestFake_Ticker(t *testing.T) {
+	c := NewFake(start)
+	ticker := c.NewTicker(time.Second)
+
+	var ticks []time.Time
+	for i := 0; i < 3; i++ {
+		c.Advance(time.Second)
+		at, ok := fired(ticker.C())
+		require.True(t, ok)
+		ticks = append(ticks, at)
+	}
+	require.Equal(t, []time.Time{start.Add(time.Second), start.Add(2 * time.Second), start.Add(3 * time.Second)}, ticks)
+
+	// Slow receiver, ticks are dropped
+	c.Advance(10 * time.Second)
+	at, ok := fired(ticker.C())
+	require.True(t, ok)
+	require.Equal(t, start.Add(4*time.Second), at)
+	_, ok = fired(ticker.C())
+	require.False(t, ok)
+
+	ticker.Stop()
+	c.Advance(time.Minute)
+	_, ok = fired(ticker.C())
+	require.False(t, ok)
+}
+
+func TestReal(t *testing.T) {
+	var c Clock = Real{}
+	timer := c.NewTimer(time.Millisecond)
+	<-timer.C()
+	ticker := c.NewTicker(time.Millisecond)
+	<-ticker.C()
+	ticker.Stop()
+	require.WithinDuration(t, time.Now(), c.Now(), time.Second)
+}
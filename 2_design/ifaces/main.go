@@ -0,0 +1,102 @@
+/*
+Ifaces reports which types implement which interfaces.
+
+Usage:
+
+	ifaces [-json] [-tests] [-std LIST] [PATTERN...]
+
+It loads the packages matching PATTERN (default "./..."), and lists every
+interface declared in them, and every standard library interface in -std, with
+the types implementing it.
+It also reports near misses, types which have all the methods of an
+interface but one, with a similar method. For example:
+
+	goiface/2_design/2_sort.Sortable almost implements sort.Interface:
+	missing Swap(i int, j int), have Swao(i int, j int) (similar name)
+
+Generic types are checked instantiated with their constraints.
+*/
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "output JSON")
+	tests := flag.Bool("tests", false, "include types in test files")
+	std := flag.String("std", strings.Join(StdInterfaces, ","), "standard library interfaces to check")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [options] [PATTERN...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	var stdNames []string
+	if *std != "" {
+		stdNames = strings.Split(*std, ",")
+	}
+
+	pkgs, err := Load("", *tests, patterns, stdNames)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+
+	r, err := NewReport(pkgs, stdNames)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		err = writeJSON(os.Stdout, r)
+	} else {
+		err = writeText(os.Stdout, r)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func writeJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func writeText(w io.Writer, r *Report) error {
+	// bufio.Writer keeps the first write error, Flush returns it
+	bw := bufio.NewWriter(w)
+	for _, iface := range r.Interfaces {
+		if iface.Std && len(iface.Implementers) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(bw, "%s\n", iface.Name)
+		for _, name := range iface.Implementers {
+			fmt.Fprintf(bw, "\t%s\n", name)
+		}
+	}
+
+	if len(r.NearMisses) > 0 {
+		fmt.Fprintf(bw, "\nnear misses:\n")
+	}
+	for _, nm := range r.NearMisses {
+		fmt.Fprintf(bw, "\t%s\n", nm)
+	}
+
+	return bw.Flush()
+}
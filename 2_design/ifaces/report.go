@@ -0,0 +1,447 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// StdInterfaces are the standard library interfaces checked by default.
+var StdInterfaces = []string{
+	"container/heap.Interface",
+	"encoding.BinaryMarshaler",
+	"encoding.TextMarshaler",
+	"encoding.TextUnmarshaler",
+	"encoding/json.Marshaler",
+	"encoding/json.Unmarshaler",
+	"error",
+	"fmt.Formatter",
+	"fmt.GoStringer",
+	"fmt.Stringer",
+	"io.Closer",
+	"io.Reader",
+	"io.ReaderAt",
+	"io.ReaderFrom",
+	"io.Seeker",
+	"io.Writer",
+	"io.WriterTo",
+	"io/fs.FS",
+	"io/fs.File",
+	"net/http.Handler",
+	"sort.Interface",
+}
+
+// Report is the interface satisfaction report.
+type Report struct {
+	Interfaces []Interface `json:"interfaces"`
+	NearMisses []NearMiss  `json:"near_misses"`
+}
+
+// Interface is an interface and the types implementing it.
+type Interface struct {
+	Name         string   `json:"name"`
+	Methods      []string `json:"methods"`
+	Std          bool     `json:"std,omitempty"`
+	Implementers []string `json:"implementers"`
+}
+
+// NearMiss is a type (or interface) which has all the methods of an interface
+// but one.
+type NearMiss struct {
+	Type      string `json:"type"`
+	Interface string `json:"interface"`
+	Missing   string `json:"missing"` // The interface method
+	Have      string `json:"have"`    // The type method
+	Reason    string `json:"reason"`
+}
+
+func (n NearMiss) String() string {
+	return fmt.Sprintf("%s almost implements %s: missing %s, have %s (%s)", n.Type, n.Interface, n.Missing, n.Have, n.Reason)
+}
+
+// named is a named type declared in a loaded package. Generic types are
+// instantiated with their constraints.
+type named struct {
+	name  string
+	typ   types.Type
+	iface *types.Interface // nil for concrete types
+	std   bool
+}
+
+// Load loads the packages matching patterns from dir, and the packages of
+// std (see StdInterfaces).
+func Load(dir string, tests bool, patterns, std []string) ([]*packages.Package, error) {
+	cfg := packages.Config{
+		Mode:  packages.NeedName | packages.NeedTypes | packages.NeedImports | packages.NeedDeps,
+		Dir:   dir,
+		Tests: tests,
+	}
+
+	all := append([]string(nil), patterns...)
+	for _, name := range std {
+		if pkg, _, ok := cutLast(name, "."); ok {
+			all = append(all, pkg)
+		}
+	}
+
+	pkgs, err := packages.Load(&cfg, all...)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			errs = append(errs, err.Error())
+		}
+	})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+
+	return pkgs, nil
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return "", s, false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+// NewReport returns the report for the types in pkgs, checking them against
+// the module interfaces and the std interfaces.
+func NewReport(pkgs []*packages.Package, std []string) (*Report, error) {
+	var local []named
+	byPath := make(map[string]*types.Package)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		byPath[pkg.PkgPath] = pkg.Types
+	})
+
+	stdPkgs := make(map[string]bool)
+	for _, name := range std {
+		pkgPath, _, _ := cutLast(name, ".")
+		stdPkgs[pkgPath] = true
+	}
+
+	for _, pkg := range dedup(pkgs) {
+		if stdPkgs[pkg.PkgPath] {
+			continue
+		}
+
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			local = append(local, newNamed(tn, false))
+		}
+	}
+
+	var ifaces []named
+	for _, n := range local {
+		if n.iface != nil {
+			ifaces = append(ifaces, n)
+		}
+	}
+
+	for _, name := range std {
+		obj, err := lookupStd(byPath, name)
+		if err != nil {
+			return nil, err
+		}
+		ifaces = append(ifaces, newNamed(obj, true))
+	}
+
+	var r Report
+	for _, iface := range ifaces {
+		if iface.iface.Empty() || !iface.iface.IsMethodSet() {
+			continue
+		}
+
+		ri := Interface{
+			Name:    iface.name,
+			Methods: methods(iface.iface),
+			Std:     iface.std,
+		}
+		for _, n := range local {
+			if n.name == iface.name {
+				continue
+			}
+
+			if name, ok := implements(n, iface.iface); ok {
+				ri.Implementers = append(ri.Implementers, name)
+				continue
+			}
+
+			if nm, ok := nearMiss(n, iface); ok {
+				r.NearMisses = append(r.NearMisses, nm)
+			}
+		}
+		sort.Slice(ri.Implementers, func(i, j int) bool {
+			return strings.TrimPrefix(ri.Implementers[i], "*") < strings.TrimPrefix(ri.Implementers[j], "*")
+		})
+		r.Interfaces = append(r.Interfaces, ri)
+	}
+
+	sort.Slice(r.Interfaces, func(i, j int) bool {
+		ri, rj := r.Interfaces[i], r.Interfaces[j]
+		if ri.Std != rj.Std {
+			return !ri.Std
+		}
+		return ri.Name < rj.Name
+	})
+	sort.Slice(r.NearMisses, func(i, j int) bool {
+		return r.NearMisses[i].String() < r.NearMisses[j].String()
+	})
+
+	return &r, nil
+}
+
+// dedup removes the test binaries, and the packages which have a test
+// variant (e.g. "p [p.test]" including the test files).
+func dedup(pkgs []*packages.Package) []*packages.Package {
+	byPath := make(map[string]*packages.Package)
+	var paths []string
+	for _, pkg := range pkgs {
+		if strings.HasSuffix(pkg.ID, ".test") {
+			continue
+		}
+		if _, ok := byPath[pkg.PkgPath]; !ok {
+			paths = append(paths, pkg.PkgPath)
+		} else if !strings.Contains(pkg.ID, " [") {
+			continue
+		}
+		byPath[pkg.PkgPath] = pkg
+	}
+
+	out := make([]*packages.Package, len(paths))
+	for i, p := range paths {
+		out[i] = byPath[p]
+	}
+	return out
+}
+
+func lookupStd(byPath map[string]*types.Package, name string) (*types.TypeName, error) {
+	pkgPath, typeName, _ := cutLast(name, ".")
+	scope := types.Universe
+	if pkgPath != "" {
+		pkg, ok := byPath[pkgPath]
+		if !ok {
+			return nil, fmt.Errorf("%s: package not loaded", name)
+		}
+		scope = pkg.Scope()
+	}
+
+	tn, ok := scope.Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("%s: not a type", name)
+	}
+	if _, ok := tn.Type().Underlying().(*types.Interface); !ok {
+		return nil, fmt.Errorf("%s: not an interface", name)
+	}
+	return tn, nil
+}
+
+func newNamed(tn *types.TypeName, std bool) named {
+	n := named{
+		name: tn.Name(),
+		typ:  tn.Type(),
+		std:  std,
+	}
+	if tn.Pkg() != nil {
+		n.name = tn.Pkg().Path() + "." + tn.Name()
+	}
+
+	// Instantiate generic types with their constraints, so that methods not
+	// depending on type parameters can be compared
+	if nt, ok := tn.Type().(*types.Named); ok && nt.TypeParams().Len() > 0 {
+		var params []string
+		var args []types.Type
+		for i := 0; i < nt.TypeParams().Len(); i++ {
+			tp := nt.TypeParams().At(i)
+			params = append(params, tp.Obj().Name())
+			args = append(args, tp.Constraint())
+		}
+		if typ, err := types.Instantiate(nil, nt, args, false); err == nil {
+			n.typ = typ
+		}
+		n.name += "[" + strings.Join(params, ", ") + "]"
+	}
+
+	n.iface, _ = n.typ.Underlying().(*types.Interface)
+	return n
+}
+
+func methods(iface *types.Interface) []string {
+	ms := make([]string, iface.NumMethods())
+	for i := range ms {
+		ms[i] = funcString(iface.Method(i))
+	}
+	return ms
+}
+
+// funcString returns the method name and signature, e.g. "Write([]byte) (int, error)".
+func funcString(fn *types.Func) string {
+	sig := strings.TrimPrefix(types.TypeString(fn.Type(), shortQualifier), "func")
+	return fn.Name() + sig
+}
+
+func shortQualifier(pkg *types.Package) string {
+	return pkg.Name()
+}
+
+// implements returns the implementer name ("T" or "*T") if n implements iface.
+func implements(n named, iface *types.Interface) (string, bool) {
+	if n.iface != nil {
+		if types.Implements(n.typ, iface) {
+			return n.name, true
+		}
+		return "", false
+	}
+
+	if types.Implements(n.typ, iface) {
+		return n.name, true
+	}
+	if types.Implements(types.NewPointer(n.typ), iface) {
+		return "*" + n.name, true
+	}
+	return "", false
+}
+
+// methodSet returns the methods of n by name, including the pointer receiver
+// ones.
+func methodSet(n named) map[string]*types.Func {
+	fns := make(map[string]*types.Func)
+	if n.iface != nil {
+		for i := 0; i < n.iface.NumMethods(); i++ {
+			fn := n.iface.Method(i)
+			fns[fn.Name()] = fn
+		}
+		return fns
+	}
+
+	ms := types.NewMethodSet(types.NewPointer(n.typ))
+	for i := 0; i < ms.Len(); i++ {
+		fn, ok := ms.At(i).Obj().(*types.Func)
+		if ok {
+			fns[fn.Name()] = fn
+		}
+	}
+	return fns
+}
+
+// nearMiss returns true if n has all the methods of iface but one, and it has
+// a method with the same name but a different signature, or a method with a
+// similar name and the same signature.
+func nearMiss(n named, iface named) (NearMiss, bool) {
+	have := methodSet(n)
+	if len(have) == 0 {
+		return NearMiss{}, false
+	}
+
+	var missing *types.Func
+	used := make(map[string]bool)
+	for i := 0; i < iface.iface.NumMethods(); i++ {
+		fn := iface.iface.Method(i)
+		if other, ok := have[fn.Name()]; ok && types.Identical(sigOf(other), sigOf(fn)) {
+			used[fn.Name()] = true
+			continue
+		}
+		if missing != nil {
+			return NearMiss{}, false // two missing
+		}
+		missing = fn
+	}
+	if missing == nil {
+		return NearMiss{}, false
+	}
+
+	nm := NearMiss{
+		Type:      n.name,
+		Interface: iface.name,
+		Missing:   funcString(missing),
+	}
+
+	// A method with the same name is a coincidence unless other methods match
+	if other, ok := have[missing.Name()]; ok && len(used) > 0 {
+		nm.Have = funcString(other)
+		nm.Reason = "wrong signature"
+		return nm, true
+	}
+
+	for _, name := range sortedNames(have) {
+		other := have[name]
+		if used[name] || !similar(name, missing.Name()) {
+			continue
+		}
+		if types.Identical(sigOf(other), sigOf(missing)) {
+			nm.Have = funcString(other)
+			nm.Reason = "similar name"
+			return nm, true
+		}
+	}
+
+	return NearMiss{}, false
+}
+
+// sigOf returns the signature of fn without the receiver, so that interface
+// and concrete methods compare.
+func sigOf(fn *types.Func) *types.Signature {
+	sig := fn.Type().(*types.Signature)
+	return types.NewSignatureType(nil, nil, nil, sig.Params(), sig.Results(), sig.Variadic())
+}
+
+func sortedNames(fns map[string]*types.Func) []string {
+	names := make([]string, 0, len(fns))
+	for name := range fns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// similar returns true if a and b differ only by case, or by a typo (two for
+// long names).
+func similar(a, b string) bool {
+	if strings.EqualFold(a, b) {
+		return true
+	}
+
+	switch d := distance(a, b); {
+	case d == 1:
+		return min(len(a), len(b)) >= 3
+	case d == 2:
+		return min(len(a), len(b)) >= 8
+	}
+	return false
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goiface/3_io/faultio"
+)
+
+func loadReport(t *testing.T, std []string, patterns ...string) *Report {
+	t.Helper()
+
+	pkgs, err := Load("../..", false, patterns, std)
+	require.NoError(t, err)
+
+	r, err := NewReport(pkgs, std)
+	require.NoError(t, err)
+	return r
+}
+
+func findInterface(r *Report, name string) (Interface, bool) {
+	for _, iface := range r.Interfaces {
+		if iface.Name == name {
+			return iface, true
+		}
+	}
+	return Interface{}, false
+}
+
+func TestReport(t *testing.T) {
+	std := []string{"io.Writer", "sort.Interface", "container/heap.Interface", "error"}
+	r := loadReport(t, std, "./2_design/2_sort", "./3_io/faultio", "./5_empty/cache", "./5_empty/challenge")
+
+	w, ok := findInterface(r, "io.Writer")
+	require.True(t, ok)
+	require.True(t, w.Std)
+	require.Equal(t, []string{"*goiface/3_io/faultio.Writer"}, w.Implementers)
+
+	h, ok := findInterface(r, "container/heap.Interface")
+	require.True(t, ok)
+	require.Equal(t, []string{"*goiface/5_empty/cache.lfuHeap[K, V]"}, h.Implementers)
+
+	store, ok := findInterface(r, "goiface/5_empty/challenge.store")
+	require.True(t, ok)
+	require.Len(t, store.Implementers, 3)
+	require.Equal(t, []string{"get() (any, bool)", "put(v any) bool"}, store.Methods)
+
+	sortable, ok := findInterface(r, "goiface/2_design/2_sort.Sortable")
+	require.True(t, ok)
+	require.Empty(t, sortable.Implementers)
+
+	require.Contains(t, r.NearMisses, NearMiss{
+		Type:      "goiface/2_design/2_sort.Sortable",
+		Interface: "sort.Interface",
+		Missing:   "Swap(i int, j int)",
+		Have:      "Swao(i int, j int)",
+		Reason:    "similar name",
+	})
+	require.Contains(t, r.NearMisses, NearMiss{
+		Type:      "goiface/5_empty/cache.lfuHeap[K, V]",
+		Interface: "goiface/2_design/2_sort.Sortable",
+		Missing:   "Swao(i int, j int)",
+		Have:      "Swap(i int, j int)",
+		Reason:    "similar name",
+	})
+}
+
+func TestReport_BadStd(t *testing.T) {
+	pkgs, err := Load("../..", false, []string{"./2_design/2_sort"}, []string{"io.Writer"})
+	require.NoError(t, err)
+
+	for _, name := range []string{"io.NoSuchType", "io.EOF", "io.SectionReader", "net.Conn"} {
+		_, err := NewReport(pkgs, []string{name})
+		require.Error(t, err, name)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	r := loadReport(t, []string{"sort.Interface"}, "./2_design/2_sort")
+
+	var buf bytes.Buffer
+	err := writeText(&buf, r)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "goiface/2_design/2_sort.Sortable\n")
+	require.Contains(t, buf.String(), "near misses:\n\tgoiface/2_design/2_sort.Sortable almost implements sort.Interface")
+	require.NotContains(t, buf.String(), "sort.Interface\n", "std interface without implementers")
+
+	w := faultio.NewWriter(io.Discard, faultio.FailAfter(10, nil))
+	require.ErrorIs(t, writeText(w, r), faultio.ErrInjected)
+
+	buf.Reset()
+	err = writeJSON(&buf, r)
+	require.NoError(t, err)
+	var out Report
+	err = json.Unmarshal(buf.Bytes(), &out)
+	require.NoError(t, err)
+	require.Equal(t, *r, out)
+}
+
+func TestSimilar(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		ok   bool
+	}{
+		{"Swao", "Swap", true},
+		{"write", "Write", true},
+		{"Len", "Less", false},
+		{"String", "GoString", false},
+		{"Unmarshal", "Unmarsal", true},
+		{"MarshalJSN", "MarshalJSON", true},
+		{"Do", "Go", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.a+"/"+tc.b, func(t *testing.T) {
+			require.Equal(t, tc.ok, similar(tc.a, tc.b))
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const mockPath = "goiface/2_design/mockgen/mock"
+
+// load parses and type checks the package in dir (without test files).
+func load(dir string) (*types.Package, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(bp.Dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	pkgPath, err := importPath(bp.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+	}
+	return conf.Check(pkgPath, fset, files, nil)
+}
+
+// importPath returns the import path of dir from the enclosing go.mod.
+func importPath(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for root := dir; ; root = filepath.Dir(root) {
+		modPath, err := modulePath(filepath.Join(root, "go.mod"))
+		if err == nil {
+			rel, err := filepath.Rel(root, dir)
+			if err != nil {
+				return "", err
+			}
+			return path.Join(modPath, filepath.ToSlash(rel)), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		if filepath.Dir(root) == root {
+			return "", fmt.Errorf("%q: not in a module", dir)
+		}
+	}
+}
+
+func modulePath(fileName string) (string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	s := bufio.NewScanner(file)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("%q: missing module directive", fileName)
+}
+
+// generator generates mocks for interfaces in src.
+type generator struct {
+	src     *types.Package
+	pkgName string            // output package name
+	local   bool              // output is in src
+	imports map[string]string // path -> name
+	buf     bytes.Buffer
+}
+
+// generate returns the formatted source code of mocks for the named
+// interfaces in src. pkgName is the output package name, "" means src.
+func generate(src *types.Package, pkgName string, names []string) ([]byte, error) {
+	g := generator{
+		src:     src,
+		pkgName: pkgName,
+		local:   pkgName == "" || pkgName == src.Name(),
+		imports: make(map[string]string),
+	}
+	if g.local {
+		g.pkgName = src.Name()
+	}
+	g.importName(mockPath, "mock")
+
+	for _, name := range names {
+		if err := g.genMock(name); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by mockgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", g.pkgName)
+	fmt.Fprintf(&out, "import (\n")
+	paths := make([]string, 0, len(g.imports))
+	for p := range g.imports {
+		paths = append(paths, p)
+	}
+	// Standard library first
+	sort.Slice(paths, func(i, j int) bool {
+		si, sj := g.isStd(paths[i]), g.isStd(paths[j])
+		if si != sj {
+			return si
+		}
+		return paths[i] < paths[j]
+	})
+	for i, p := range paths {
+		if i > 0 && g.isStd(paths[i-1]) && !g.isStd(p) {
+			fmt.Fprintln(&out)
+		}
+		if name := g.imports[p]; name != path.Base(p) {
+			fmt.Fprintf(&out, "\t%s %q\n", name, p)
+		} else {
+			fmt.Fprintf(&out, "\t%q\n", p)
+		}
+	}
+	fmt.Fprintf(&out, ")\n")
+	out.Write(g.buf.Bytes())
+
+	code, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("bad generated code - %w", err)
+	}
+	return code, nil
+}
+
+// isStd returns true if pkgPath is in the standard library, module paths
+// without a dot (like this one) are detected from the source and mock paths.
+func (g *generator) isStd(pkgPath string) bool {
+	first := func(p string) string {
+		elem, _, _ := strings.Cut(p, "/")
+		return elem
+	}
+
+	elem := first(pkgPath)
+	if elem == first(g.src.Path()) || elem == first(mockPath) {
+		return false
+	}
+	return !strings.Contains(elem, ".")
+}
+
+// importName adds an import of pkgPath and returns its name in the generated
+// file, renaming it on conflicts.
+func (g *generator) importName(pkgPath, name string) string {
+	if name, ok := g.imports[pkgPath]; ok {
+		return name
+	}
+
+	base := name
+	for i := 2; g.nameTaken(name); i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.imports[pkgPath] = name
+	return name
+}
+
+func (g *generator) nameTaken(name string) bool {
+	for _, other := range g.imports {
+		if other == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) qualifier(pkg *types.Package) string {
+	if g.local && pkg == g.src {
+		return ""
+	}
+	return g.importName(pkg.Path(), pkg.Name())
+}
+
+func (g *generator) typeString(typ types.Type) string {
+	return types.TypeString(typ, g.qualifier)
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// mockName returns the mock type name for an interface, keeping it
+// unexported if the interface is.
+func mockName(name string) string {
+	if token.IsExported(name) {
+		return "Mock" + name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return "mock" + string(r)
+}
+
+func (g *generator) genMock(name string) error {
+	obj := g.src.Scope().Lookup(name)
+	if obj == nil {
+		return fmt.Errorf("%s.%s not found", g.src.Path(), name)
+	}
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return fmt.Errorf("%s.%s is not a type", g.src.Path(), name)
+	}
+	iface, ok := tn.Type().Underlying().(*types.Interface)
+	if !ok {
+		return fmt.Errorf("%s.%s is not an interface", g.src.Path(), name)
+	}
+
+	// Type parameters, e.g. "[In, Out any]" & "[In, Out]"
+	var tparams, targs string
+	if named, ok := tn.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+		var decls, args []string
+		for i := 0; i < named.TypeParams().Len(); i++ {
+			tp := named.TypeParams().At(i)
+			decls = append(decls, tp.Obj().Name()+" "+g.typeString(tp.Constraint()))
+			args = append(args, tp.Obj().Name())
+		}
+		tparams = "[" + strings.Join(decls, ", ") + "]"
+		targs = "[" + strings.Join(args, ", ") + "]"
+	}
+
+	ifaceName := name
+	if !g.local {
+		ifaceName = g.qualifier(g.src) + "." + name
+	}
+	mock := mockName(name)
+	newName := "New" + mock
+	if !token.IsExported(name) {
+		newName = "new" + strings.TrimPrefix(mock, "mock")
+	}
+
+	g.printf("\n// %s is a mock %s.\n", mock, ifaceName)
+	g.printf("type %s%s struct {\n\tmock.Mock\n}\n", mock, tparams)
+	g.printf("\n// %s returns a %s, its expectations are checked when t finishes.\n", newName, mock)
+	g.printf("func %s%s(t mock.T) *%s%s {\n", newName, tparams, mock, targs)
+	g.printf("\tvar m %s%s\n\tm.Mock.Init(t)\n\treturn &m\n}\n", mock, targs)
+
+	// Add the imports first, parameter names must not shadow them
+	for i := 0; i < iface.NumMethods(); i++ {
+		g.typeString(iface.Method(i).Type())
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		g.genMethod(ifaceName, mock, tparams, targs, iface.Method(i))
+	}
+
+	return nil
+}
+
+// param is a method parameter or result.
+type param struct {
+	name string
+	typ  string // with "..." for variadic parameters
+}
+
+// params returns the tuple variables, renaming the unnamed and conflicting
+// ones to prefix + index.
+func (g *generator) params(tuple *types.Tuple, prefix string, variadic bool, reserved ...string) []param {
+	taken := func(name string) bool {
+		if name == "" || name == "_" || g.nameTaken(name) {
+			return true
+		}
+		for _, r := range reserved {
+			if name == r {
+				return true
+			}
+		}
+		return false
+	}
+
+	ps := make([]param, tuple.Len())
+	for i := range ps {
+		typ := tuple.At(i).Type()
+		if variadic && i == len(ps)-1 {
+			ps[i].typ = "..." + g.typeString(typ.(*types.Slice).Elem())
+		} else {
+			ps[i].typ = g.typeString(typ)
+		}
+
+		ps[i].name = tuple.At(i).Name()
+		if taken(ps[i].name) {
+			ps[i].name = fmt.Sprintf("%s%d", prefix, i)
+		}
+	}
+	return ps
+}
+
+func joinParams(ps []param, typed bool) string {
+	strs := make([]string, len(ps))
+	for i, p := range ps {
+		strs[i] = p.name
+		if typed {
+			strs[i] += " " + p.typ
+		}
+	}
+	return strings.Join(strs, ", ")
+}
+
+func (g *generator) genMethod(ifaceName, mock, tparams, targs string, fn *types.Func) {
+	sig := fn.Type().(*types.Signature)
+	name := fn.Name()
+	args := g.params(sig.Params(), "arg", sig.Variadic(), "m", "rets")
+	rets := g.params(sig.Results(), "r", false, "c")
+
+	retTypes := make([]string, len(rets))
+	for i, r := range rets {
+		retTypes[i] = r.typ
+	}
+	results := strings.Join(retTypes, ", ")
+	if len(rets) > 1 {
+		results = "(" + results + ")"
+	}
+
+	called := fmt.Sprintf("m.Mock.Called(%q", name)
+	if len(args) > 0 {
+		called += ", " + joinParams(args, false)
+	}
+	called += ")"
+
+	// Method
+	g.printf("\n// %s implements %s.\n", name, ifaceName)
+	g.printf("func (m *%s%s) %s(%s) %s {\n", mock, targs, name, joinParams(args, true), results)
+	if len(rets) == 0 {
+		g.printf("\t%s\n}\n", called)
+	} else {
+		vals := make([]string, len(rets))
+		for i, r := range rets {
+			vals[i] = fmt.Sprintf("mock.Ret[%s](rets, %d)", r.typ, i)
+		}
+		g.printf("\trets := %s\n\treturn %s\n}\n", called, strings.Join(vals, ", "))
+	}
+
+	// Expectation
+	call := mock + name + "Call"
+	on := fmt.Sprintf("m.Mock.On(%q", name)
+	if len(args) > 0 {
+		on += ", " + joinParams(args, false)
+	}
+	on += ")"
+	onArgs := joinParams(args, false)
+	if len(args) > 0 {
+		onArgs += " any"
+	}
+
+	g.printf("\n// %s is an expected %s call.\n", call, name)
+	g.printf("type %s%s struct {\n\t*mock.Call\n}\n", call, tparams)
+	g.printf("\n// On%s expects a %s call, arguments are values or mock.Matcher.\n", name, name)
+	g.printf("func (m *%s%s) On%s(%s) %s%s {\n", mock, targs, name, onArgs, call, targs)
+	g.printf("\treturn %s%s{%s}\n}\n", call, targs, on)
+
+	if len(rets) > 0 {
+		g.printf("\n// Return adds return values to the call sequence.\n")
+		g.printf("func (c %s%s) Return(%s) %s%s {\n", call, targs, joinParams(rets, true), call, targs)
+		g.printf("\tc.Call.Return(%s)\n\treturn c\n}\n", joinParams(rets, false))
+	}
+
+	g.printf("\n// Times sets the exact number of expected calls.\n")
+	g.printf("func (c %s%s) Times(n int) %s%s {\n", call, targs, call, targs)
+	g.printf("\tc.Call.Times(n)\n\treturn c\n}\n")
+}
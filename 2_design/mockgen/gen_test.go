@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var goldenCases = []struct {
+	dir   string
+	pkg   string
+	iface string
+}{
+	{"../../1_go/1_when/logs", "", "DB"},
+	{"../challenge", "", "Puller"},
+	{"../5_perf", "", "UserIter"},
+	{"../2_sort", "", "Sortable"},
+	{"../../3_io/pipeline", "pipeline_test", "Stage"},
+}
+
+func TestGenerate(t *testing.T) {
+	for _, tc := range goldenCases {
+		t.Run(tc.iface, func(t *testing.T) {
+			src, err := load(tc.dir)
+			require.NoError(t, err)
+
+			code, err := generate(src, tc.pkg, []string{tc.iface})
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", tc.iface+".golden")
+			if *update {
+				err := os.WriteFile(golden, code, 0666)
+				require.NoError(t, err)
+			}
+
+			data, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(data), string(code))
+		})
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	src, err := load("../../1_go/1_when/logs")
+	require.NoError(t, err)
+
+	for _, name := range []string{"NoSuchType", "Log", "InfoLevel"} {
+		t.Run(name, func(t *testing.T) {
+			_, err := generate(src, "", []string{name})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestImportPath(t *testing.T) {
+	path, err := importPath(filepath.Join("..", "..", "3_io", "wfs"))
+	require.NoError(t, err)
+	require.Equal(t, "goiface/3_io/wfs", path)
+
+	_, err = importPath(t.TempDir())
+	require.Error(t, err)
+}
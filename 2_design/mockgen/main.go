@@ -0,0 +1,62 @@
+/*
+Mockgen generates mocks for interfaces, using the mock package.
+
+Usage:
+
+	mockgen [-dir DIR] [-pkg NAME] [-out FILE] INTERFACE...
+
+Use it with go generate, for example:
+
+	//go:generate go run goiface/2_design/mockgen -out mock_db_test.go DB
+
+For every interface I, mockgen generates MockI with the interface methods and:
+  - NewMockI(t) which checks the expectations when the test finishes
+  - OnMethod(args) which expects a call, arguments are values or mock.Matcher
+  - Return(values) on the expected call, called several times it scripts a
+    return sequence
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "package directory")
+	pkgName := flag.String("pkg", "", "output package name (default to the package in dir)")
+	out := flag.String("out", "", "output file (default to stdout)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [options] INTERFACE...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	src, err := load(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %q - %s\n", *dir, err)
+		os.Exit(1)
+	}
+
+	code, err := generate(src, *pkgName, flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(code)
+		return
+	}
+
+	if err := os.WriteFile(*out, code, 0666); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
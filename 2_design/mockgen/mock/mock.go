@@ -0,0 +1,282 @@
+// Package mock is the runtime support for mocks generated by mockgen.
+package mock
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// T is the subset of testing.TB used by mocks.
+type T interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Cleanup(func())
+}
+
+// Matcher matches a call argument.
+type Matcher interface {
+	Match(v any) bool
+	String() string
+}
+
+type anyMatcher struct{}
+
+func (anyMatcher) Match(any) bool {
+	return true
+}
+
+func (anyMatcher) String() string {
+	return "Any()"
+}
+
+// Any matches any value.
+func Any() Matcher {
+	return anyMatcher{}
+}
+
+type eqMatcher struct {
+	v any
+}
+
+func (m eqMatcher) Match(v any) bool {
+	return reflect.DeepEqual(m.v, v)
+}
+
+func (m eqMatcher) String() string {
+	return fmt.Sprintf("%#v", m.v)
+}
+
+// Eq matches values equal to v (using reflect.DeepEqual).
+func Eq(v any) Matcher {
+	return eqMatcher{v}
+}
+
+type funcMatcher[V any] struct {
+	desc string
+	fn   func(V) bool
+}
+
+func (m funcMatcher[V]) Match(v any) bool {
+	tv, ok := v.(V)
+	if !ok && v != nil {
+		return false
+	}
+	return m.fn(tv)
+}
+
+func (m funcMatcher[V]) String() string {
+	return m.desc
+}
+
+// Func matches values of type V where fn returns true, desc describes the
+// matcher in error messages.
+func Func[V any](desc string, fn func(V) bool) Matcher {
+	return funcMatcher[V]{desc, fn}
+}
+
+// Call is an expected call.
+type Call struct {
+	method  string
+	args    []Matcher
+	returns [][]any
+	times   int // 0 is any number of times
+	calls   int
+}
+
+// Return adds return values to the call sequence. The first call returns the
+// first values, the second call the second ones... the last values are
+// returned once the sequence is exhausted.
+func (c *Call) Return(values ...any) *Call {
+	c.returns = append(c.returns, values)
+	return c
+}
+
+// Times sets the exact number of expected calls.
+func (c *Call) Times(n int) *Call {
+	c.times = n
+	return c
+}
+
+func (c *Call) String() string {
+	args := make([]string, len(c.args))
+	for i, m := range c.args {
+		args[i] = m.String()
+	}
+	return fmt.Sprintf("%s(%s)", c.method, strings.Join(args, ", "))
+}
+
+func (c *Call) match(method string, args []any) bool {
+	if c.method != method || len(c.args) != len(args) {
+		return false
+	}
+
+	if c.times > 0 && c.calls >= c.times {
+		return false
+	}
+
+	for i, m := range c.args {
+		if !m.Match(args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Mock records calls and matches them against expectations.
+// Embed it in a mock type, see mockgen.
+type Mock struct {
+	mu       sync.Mutex
+	t        T
+	expected []*Call
+	calls    map[string][][]any
+}
+
+// Init sets the mock test, expectations are checked when t finishes.
+func (m *Mock) Init(t T) {
+	m.t = t
+	t.Cleanup(func() {
+		m.AssertExpectations(t)
+	})
+}
+
+// On adds an expected call to method, args are either Matcher or values
+// matched with Eq.
+func (m *Mock) On(method string, args ...any) *Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := Call{method: method}
+	for _, a := range args {
+		if mt, ok := a.(Matcher); ok {
+			c.args = append(c.args, mt)
+		} else {
+			c.args = append(c.args, Eq(a))
+		}
+	}
+	m.expected = append(m.expected, &c)
+	return &c
+}
+
+// Called records a call and returns the return values of the first matching
+// expectation. Unexpected calls are reported to the test and return nil.
+func (m *Mock) Called(method string, args ...any) []any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.calls == nil {
+		m.calls = make(map[string][][]any)
+	}
+	m.calls[method] = append(m.calls[method], args)
+
+	for _, c := range m.expected {
+		if !c.match(method, args) {
+			continue
+		}
+
+		c.calls++
+		if len(c.returns) == 0 {
+			return nil
+		}
+		i := min(c.calls, len(c.returns)) - 1
+		return c.returns[i]
+	}
+
+	if m.t != nil {
+		m.t.Helper()
+		m.t.Errorf("mock: unexpected call %s%s", method, formatArgs(args))
+	}
+	return nil
+}
+
+// Calls returns the arguments of every call to method.
+func (m *Mock) Calls(method string) [][]any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.calls[method]
+}
+
+// AssertExpectations checks that every expected call was made (Times times if
+// set).
+func (m *Mock) AssertExpectations(t T) bool {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+	for _, c := range m.expected {
+		switch {
+		case c.times > 0 && c.calls != c.times:
+			t.Errorf("mock: %s called %d times, expected %d", c, c.calls, c.times)
+			ok = false
+		case c.calls == 0:
+			t.Errorf("mock: %s not called", c)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertCalled checks that method was called with args matching args (Matcher
+// or values).
+func (m *Mock) AssertCalled(t T, method string, args ...any) bool {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := Call{method: method}
+	for _, a := range args {
+		if mt, ok := a.(Matcher); ok {
+			c.args = append(c.args, mt)
+		} else {
+			c.args = append(c.args, Eq(a))
+		}
+	}
+
+	for _, called := range m.calls[method] {
+		if c.match(method, called) {
+			return true
+		}
+	}
+
+	t.Errorf("mock: %s not called", &c)
+	return false
+}
+
+// AssertNumberOfCalls checks that method was called n times.
+func (m *Mock) AssertNumberOfCalls(t T, method string, n int) bool {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if got := len(m.calls[method]); got != n {
+		t.Errorf("mock: %s called %d times, expected %d", method, got, n)
+		return false
+	}
+	return true
+}
+
+// Ret returns the i'th value in values as a V, or the zero value if it is
+// missing or nil.
+func Ret[V any](values []any, i int) V {
+	var zero V
+	if i >= len(values) || values[i] == nil {
+		return zero
+	}
+
+	v, ok := values[i].(V)
+	if !ok {
+		panic(fmt.Sprintf("mock: return value %d is %T, expected %T", i, values[i], zero))
+	}
+	return v
+}
+
+func formatArgs(args []any) string {
+	strs := make([]string, len(args))
+	for i, a := range args {
+		strs[i] = fmt.Sprintf("%#v", a)
+	}
+	return "(" + strings.Join(strs, ", ") + ")"
+}
@@ -0,0 +1,111 @@
+package mock
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordT records errors and cleanups.
+type recordT struct {
+	errors   []string
+	cleanups []func()
+}
+
+func (t *recordT) Helper() {}
+
+func (t *recordT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *recordT) Cleanup(fn func()) {
+	t.cleanups = append(t.cleanups, fn)
+}
+
+func (t *recordT) finish() {
+	for _, fn := range t.cleanups {
+		fn()
+	}
+}
+
+func TestMock_Return(t *testing.T) {
+	var rt recordT
+	var m Mock
+	m.Init(&rt)
+
+	m.On("Get", "a").Return(1, nil).Return(2, nil)
+	m.On("Get", Any()).Return(0, fmt.Errorf("not found"))
+
+	require.Equal(t, []any{1, nil}, m.Called("Get", "a"))
+	require.Equal(t, []any{2, nil}, m.Called("Get", "a"))
+	require.Equal(t, []any{2, nil}, m.Called("Get", "a"), "last values repeat")
+
+	rets := m.Called("Get", "b")
+	require.Equal(t, 0, Ret[int](rets, 0))
+	require.Error(t, Ret[error](rets, 1))
+
+	rt.finish()
+	require.Empty(t, rt.errors)
+	require.True(t, m.AssertNumberOfCalls(&rt, "Get", 4))
+	require.True(t, m.AssertCalled(&rt, "Get", "b"))
+}
+
+func TestMock_Matchers(t *testing.T) {
+	var rt recordT
+	var m Mock
+	m.Init(&rt)
+
+	even := Func("even", func(n int) bool { return n%2 == 0 })
+	m.On("Add", even, Eq(1)).Return(true)
+
+	require.Equal(t, []any{true}, m.Called("Add", 2, 1))
+	require.Nil(t, m.Called("Add", 3, 1))
+	require.Nil(t, m.Called("Add", "2", 1))
+
+	require.Len(t, rt.errors, 2)
+	require.Contains(t, rt.errors[0], `unexpected call Add(3, 1)`)
+	require.Len(t, m.Calls("Add"), 3)
+}
+
+func TestMock_Times(t *testing.T) {
+	var rt recordT
+	var m Mock
+	m.Init(&rt)
+
+	m.On("Close").Times(1)
+	m.On("Flush").Times(2)
+	m.On("Sync")
+
+	m.Called("Close")
+	m.Called("Close")
+	m.Called("Flush")
+
+	rt.finish()
+	errs := strings.Join(rt.errors, "\n")
+	require.Contains(t, errs, "unexpected call Close()")
+	require.Contains(t, errs, "Flush() called 1 times, expected 2")
+	require.Contains(t, errs, "Sync() not called")
+}
+
+func TestMock_AssertCalled(t *testing.T) {
+	var rt recordT
+	var m Mock
+
+	m.On("Write", Any())
+	m.Called("Write", []byte("hi"))
+
+	require.True(t, m.AssertCalled(&rt, "Write", []byte("hi")))
+	require.False(t, m.AssertCalled(&rt, "Write", []byte("bye")))
+	require.False(t, m.AssertNumberOfCalls(&rt, "Write", 2))
+	require.Len(t, rt.errors, 2)
+}
+
+func TestRet(t *testing.T) {
+	rets := []any{1, nil}
+	require.Equal(t, 1, Ret[int](rets, 0))
+	require.Nil(t, Ret[error](rets, 1))
+	require.Equal(t, "", Ret[string](rets, 2))
+	require.Panics(t, func() { Ret[string](rets, 0) })
+}
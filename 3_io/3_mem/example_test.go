@@ -11,3 +11,18 @@ func ExampleList() {
 	// - butter
 	// - orange juice
 }
+
+func ExampleTable() {
+	header := []string{"item", "price"}
+	rows := [][]string{
+		{"bread", "2.5"},
+		{"butter | salted", "3"},
+	}
+	fmt.Print(Table(header, rows))
+
+	// Output:
+	// | item | price |
+	// | --- | --- |
+	// | bread | 2.5 |
+	// | butter \| salted | 3 |
+}
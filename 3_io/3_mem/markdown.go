@@ -3,6 +3,7 @@ package markdown
 import (
 	"bytes"
 	"fmt"
+	"strings"
 )
 
 // List renders a slice of item to a markdown list.
@@ -14,3 +15,32 @@ func List(items []string) string {
 	}
 	return buf.String()
 }
+
+// Table renders a header and rows to a markdown table. Pipes in cells are
+// escaped, rows shorter than the header are padded with empty cells.
+func Table(header []string, rows [][]string) string {
+	var buf bytes.Buffer
+
+	writeRow := func(cells []string) {
+		buf.WriteString("|")
+		for i := range header {
+			cell := ""
+			if i < len(cells) {
+				cell = strings.ReplaceAll(cells[i], "|", `\|`)
+			}
+			fmt.Fprintf(&buf, " %s |", cell)
+		}
+		buf.WriteString("\n")
+	}
+
+	writeRow(header)
+	buf.WriteString("|")
+	for range header {
+		buf.WriteString(" --- |")
+	}
+	buf.WriteString("\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+	return buf.String()
+}
@@ -1,28 +1,81 @@
 package rotate
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
 	"path"
+	"slices"
+	"time"
+
+	"goiface/2_design/2_sort"
+	"goiface/2_design/clock"
+	"goiface/3_io/wfs"
 )
 
 type Rotator struct {
+	fsys     wfs.FS
 	rootPath string
 	n        int
 	maxSize  int
 	size     int
-	out      *os.File
+	maxAge   time.Duration
+	opened   time.Time
+	clock    clock.Clock
+	wrap     func(io.WriteCloser) (io.WriteCloser, error)
+	out      io.WriteCloser
+	err      error // Sticky rotation error
+}
+
+// Option configures a Rotator.
+type Option func(*Rotator)
+
+// WithMaxAge rotates files once they are older than d.
+func WithMaxAge(d time.Duration) Option {
+	return func(r *Rotator) {
+		r.maxAge = d
+	}
 }
 
-func New(rootPath string, maxSize int) (*Rotator, error) {
-	if err := os.MkdirAll(rootPath, 0700); err != nil {
+// WithClock sets the clock used by WithMaxAge, the default is the system
+// clock.
+func WithClock(c clock.Clock) Option {
+	return func(r *Rotator) {
+		r.clock = c
+	}
+}
+
+// WithWrapper wraps every log file with wrap, e.g. to compress or encrypt
+// it. The wrapper must close the file it wraps. The maximal size is of the
+// data written to the wrapper.
+func WithWrapper(wrap func(io.WriteCloser) (io.WriteCloser, error)) Option {
+	return func(r *Rotator) {
+		r.wrap = wrap
+	}
+}
+
+// New returns a Rotator writing log files in rootPath on disk.
+func New(rootPath string, maxSize int, opts ...Option) (*Rotator, error) {
+	return NewFS(wfs.DirFS(rootPath), ".", maxSize, opts...)
+}
+
+// NewFS returns a Rotator writing log files in rootPath in fsys.
+func NewFS(fsys wfs.FS, rootPath string, maxSize int, opts ...Option) (*Rotator, error) {
+	if err := fsys.MkdirAll(rootPath, 0700); err != nil {
 		return nil, err
 	}
 
 	r := Rotator{
+		fsys:     fsys,
 		rootPath: rootPath,
 		maxSize:  maxSize,
+		clock:    clock.Real{},
 	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+
 	if err := r.rotate(); err != nil {
 		return nil, err
 	}
@@ -31,6 +84,16 @@ func New(rootPath string, maxSize int) (*Rotator, error) {
 }
 
 func (r *Rotator) Write(data []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	if r.maxAge > 0 && r.clock.Now().Sub(r.opened) >= r.maxAge {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
 	if n, err := r.out.Write(data); err != nil {
 		return n, err
 	}
@@ -46,26 +109,59 @@ func (r *Rotator) Write(data []byte) (int, error) {
 }
 
 func (r *Rotator) Close() error {
+	if r.err == nil {
+		r.err = fs.ErrClosed
+	}
 	if r.out == nil {
 		return nil
 	}
 
-	return r.out.Close()
+	err := r.out.Close()
+	r.out = nil
+	return err
+}
+
+// Segments returns the log files in rootPath in fsys, in rotation order
+// (log-100.txt is after log-99.txt).
+func Segments(fsys fs.FS, rootPath string) ([]string, error) {
+	matches, err := fs.Glob(fsys, path.Join(rootPath, "log-*.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(matches, sort.Natural)
+	return matches, nil
 }
 
+// rotate closes the current log file and opens the next one. It returns the
+// close error once the next file is open, since wrappers write on close. If
+// the next file can't be opened, the error is returned by later writes.
 func (r *Rotator) rotate() error {
+	var closeErr error
 	if r.out != nil {
-		r.out.Close()
+		closeErr = r.out.Close()
+		r.out = nil
 	}
 
-	r.n++
-	fileName := path.Join(r.rootPath, fmt.Sprintf("log-%02d.txt", r.n))
-	file, err := os.Create(fileName)
+	fileName := path.Join(r.rootPath, fmt.Sprintf("log-%02d.txt", r.n+1))
+	file, err := wfs.Create(r.fsys, fileName)
 	if err != nil {
-		return err
+		r.err = errors.Join(closeErr, err)
+		return r.err
 	}
 
+	var out io.WriteCloser = file
+	if r.wrap != nil {
+		if out, err = r.wrap(file); err != nil {
+			file.Close()
+			r.err = errors.Join(closeErr, err)
+			return r.err
+		}
+	}
+
+	r.n++
 	r.size = 0
-	r.out = file
-	return nil
+	r.opened = r.clock.Now()
+	r.out = out
+	return closeErr
 }
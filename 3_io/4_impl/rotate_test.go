@@ -1,11 +1,21 @@
 package rotate
 
 import (
+	"bytes"
+	"io"
+	"io/fs"
 	"log"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"goiface/2_design/clock"
+	"goiface/3_io/crypt"
+	"goiface/3_io/faultio"
+	"goiface/3_io/wfs"
 )
 
 func TestRotator(t *testing.T) {
@@ -25,3 +35,192 @@ func TestRotator(t *testing.T) {
 	require.NoError(t, err, "glob")
 	require.Equal(t, len(matches), 2)
 }
+
+func TestRotator_FS(t *testing.T) {
+	fsys := wfs.NewMemFS()
+	out, err := NewFS(fsys, "logs", 100)
+	require.NoError(t, err, "New")
+
+	logger := log.New(out, "[test] ", 0)
+	for i := 0; i < 5; i++ {
+		logger.Printf("info: Go Rocks!")
+	}
+	require.NoError(t, out.Close())
+
+	matches, err := fs.Glob(fsys, "logs/log*.txt")
+	require.NoError(t, err, "glob")
+	require.Equal(t, []string{"logs/log-01.txt", "logs/log-02.txt"}, matches)
+}
+
+func TestRotator_WriteError(t *testing.T) {
+	fsys := wfs.NewMemFS()
+	fsys.Inject(wfs.Fault{Op: wfs.OpWrite, After: 10})
+	out, err := NewFS(fsys, "logs", 100)
+	require.NoError(t, err, "New")
+
+	_, err = out.Write([]byte("Go Rocks! Go Rocks!"))
+	require.ErrorIs(t, err, faultio.ErrInjected)
+}
+
+func TestRotator_CreateError(t *testing.T) {
+	fsys := wfs.NewMemFS()
+	fsys.Inject(wfs.Fault{Op: wfs.OpCreate, Name: "logs/log-02.txt"})
+	out, err := NewFS(fsys, "logs", 10)
+	require.NoError(t, err, "New")
+
+	data := []byte("Go Rocks! Go Rocks!")
+	n, err := out.Write(data) // over maxSize, rotates
+	require.ErrorIs(t, err, faultio.ErrInjected)
+	require.Equal(t, len(data), n)
+
+	_, err = out.Write(data)
+	require.ErrorIs(t, err, faultio.ErrInjected)
+	require.NoError(t, out.Close())
+}
+
+func TestRotator_CloseError(t *testing.T) {
+	fsys := wfs.NewMemFS()
+	fsys.Inject(wfs.Fault{Op: wfs.OpClose})
+	out, err := NewFS(fsys, "logs", 100)
+	require.NoError(t, err, "New")
+	require.ErrorIs(t, out.Close(), faultio.ErrInjected)
+}
+
+func TestNew_Error(t *testing.T) {
+	fsys := wfs.NewMemFS()
+	fsys.Inject(wfs.Fault{Op: wfs.OpMkdir})
+
+	_, err := NewFS(fsys, "logs", 100)
+	require.ErrorIs(t, err, faultio.ErrInjected)
+}
+
+func TestRotator_MaxAge(t *testing.T) {
+	fsys := wfs.NewMemFS()
+	clk := clock.NewFake(time.Date(2024, 2, 11, 20, 27, 31, 0, time.UTC))
+	out, err := NewFS(fsys, "logs", 1000, WithMaxAge(time.Hour), WithClock(clk))
+	require.NoError(t, err, "New")
+
+	logger := log.New(out, "[test] ", 0)
+	for i := 0; i < 5; i++ {
+		logger.Printf("info: Go Rocks!")
+		clk.Advance(20 * time.Minute)
+	}
+	require.NoError(t, out.Close())
+
+	matches, err := fs.Glob(fsys, "logs/log*.txt")
+	require.NoError(t, err, "glob")
+	require.Equal(t, []string{"logs/log-01.txt", "logs/log-02.txt"}, matches)
+
+	data, err := fs.ReadFile(fsys, "logs/log-02.txt")
+	require.NoError(t, err)
+	require.Equal(t, "[test] info: Go Rocks!\n[test] info: Go Rocks!\n", string(data))
+}
+
+func TestRotator_Wrapper(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	encrypt := func(w io.WriteCloser) (io.WriteCloser, error) {
+		return crypt.NewWriter(w, key)
+	}
+
+	fsys := wfs.NewMemFS()
+	out, err := NewFS(fsys, "logs", 40, WithWrapper(encrypt))
+	require.NoError(t, err, "New")
+
+	logger := log.New(out, "[test] ", 0)
+	for i := 0; i < 3; i++ {
+		logger.Printf("info: Go Rocks!")
+	}
+	require.NoError(t, out.Close())
+
+	segments, err := Segments(fsys, "logs")
+	require.NoError(t, err)
+	require.Equal(t, []string{"logs/log-01.txt", "logs/log-02.txt"}, segments)
+
+	var plain bytes.Buffer
+	for _, name := range segments {
+		file, err := fsys.Open(name)
+		require.NoError(t, err)
+		r, err := crypt.NewReader(file, key)
+		require.NoError(t, err)
+		_, err = io.Copy(&plain, r)
+		require.NoError(t, err, name)
+		file.Close()
+	}
+	require.Equal(t, strings.Repeat("[test] info: Go Rocks!\n", 3), plain.String())
+}
+
+func TestRotator_WrapperCloseError(t *testing.T) {
+	fsys := wfs.NewMemFS()
+	fsys.Inject(wfs.Fault{Op: wfs.OpClose, Name: "logs/log-01.txt"})
+	wrap := func(w io.WriteCloser) (io.WriteCloser, error) {
+		return crypt.NewWriter(w, make([]byte, 16))
+	}
+	out, err := NewFS(fsys, "logs", 10, WithWrapper(wrap))
+	require.NoError(t, err, "New")
+
+	// The last chunk of log-01.txt is lost, the next file is still opened
+	_, err = out.Write([]byte("Go Rocks! Go Rocks!"))
+	require.ErrorIs(t, err, faultio.ErrInjected)
+	_, err = out.Write([]byte("Go Rocks!"))
+	require.NoError(t, err)
+	require.NoError(t, out.Close())
+}
+
+func TestRotator_WrapperError(t *testing.T) {
+	fsys := wfs.NewMemFS()
+	calls := 0
+	wrap := func(w io.WriteCloser) (io.WriteCloser, error) {
+		calls++
+		if calls == 2 {
+			return nil, faultio.ErrInjected
+		}
+		return w, nil
+	}
+	out, err := NewFS(fsys, "logs", 10, WithWrapper(wrap))
+	require.NoError(t, err, "New")
+
+	data := []byte("Go Rocks! Go Rocks!")
+	n, err := out.Write(data) // over maxSize, rotates
+	require.ErrorIs(t, err, faultio.ErrInjected)
+	require.Equal(t, len(data), n)
+
+	// Sticky, the closed log-01.txt is not written to again
+	_, err = out.Write(data)
+	require.ErrorIs(t, err, faultio.ErrInjected)
+	require.NoError(t, out.Close())
+	require.Equal(t, 2, calls)
+
+	data, err = fs.ReadFile(fsys, "logs/log-01.txt")
+	require.NoError(t, err)
+	require.Equal(t, "Go Rocks! Go Rocks!", string(data))
+}
+
+func TestRotator_WriteAfterClose(t *testing.T) {
+	out, err := NewFS(wfs.NewMemFS(), "logs", 100)
+	require.NoError(t, err, "New")
+	require.NoError(t, out.Close())
+	require.NoError(t, out.Close())
+
+	_, err = out.Write([]byte("Go Rocks!"))
+	require.ErrorIs(t, err, fs.ErrClosed)
+}
+
+func TestSegments(t *testing.T) {
+	fsys := wfs.NewMemFS()
+	out, err := NewFS(fsys, "logs", 1)
+	require.NoError(t, err, "New")
+
+	for i := 0; i < 100; i++ {
+		_, err := out.Write([]byte("x\n")) // over maxSize, rotates
+		require.NoError(t, err)
+	}
+	require.NoError(t, out.Close())
+
+	segments, err := Segments(fsys, "logs")
+	require.NoError(t, err)
+	require.Len(t, segments, 101)
+	require.Equal(t, "logs/log-01.txt", segments[0])
+	require.Equal(t, "logs/log-99.txt", segments[98])
+	require.Equal(t, "logs/log-100.txt", segments[99])
+	require.Equal(t, "logs/log-101.txt", segments[100])
+}
@@ -0,0 +1,169 @@
+// Package checksum computes and verifies checksums of data as it's copied.
+//
+// A Reader or a Writer passes data through to the hashes of its checks. A
+// Reader verifies the expected sums when it reaches io.EOF, so io.Copy
+// returns a *MismatchError for corrupted data:
+//
+//	want, _ := hex.DecodeString("a1b2...")
+//	r := checksum.NewReader(resp.Body, checksum.SHA256(want), checksum.CRC32C(nil))
+//	if _, err := io.Copy(file, r); err != nil {
+//		return err
+//	}
+//	crc := r.Sum("crc32c")
+package checksum
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+)
+
+// Check is a hash of the data and its expected sum.
+type Check struct {
+	Name string // Name in errors and for Sum, e.g. "sha256"
+	Hash hash.Hash
+	Want []byte // Expected sum, nil to only compute it
+}
+
+// SHA256 returns a SHA-256 check, want can be nil.
+func SHA256(want []byte) Check {
+	return Check{Name: "sha256", Hash: sha256.New(), Want: want}
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// CRC32C returns a CRC-32 check with the Castagnoli polynomial, want can be
+// nil.
+func CRC32C(want []byte) Check {
+	return Check{Name: "crc32c", Hash: crc32.New(castagnoli), Want: want}
+}
+
+// FNV64a returns a 64-bit FNV-1a check, a fast non-cryptographic hash, want
+// can be nil.
+func FNV64a(want []byte) Check {
+	return Check{Name: "fnv64a", Hash: fnv.New64a(), Want: want}
+}
+
+// MismatchError is returned when a sum is not the expected one.
+type MismatchError struct {
+	Name string
+	Want []byte
+	Got  []byte
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("checksum: %s mismatch - want %x, got %x", e.Name, e.Want, e.Got)
+}
+
+// checks are the checks of a Reader or a Writer.
+type checks []Check
+
+func (cs checks) write(data []byte) {
+	for _, c := range cs {
+		c.Hash.Write(data) // Never returns an error
+	}
+}
+
+func (cs checks) sum(name string) []byte {
+	for _, c := range cs {
+		if c.Name == name {
+			return c.Hash.Sum(nil)
+		}
+	}
+	return nil
+}
+
+// verify returns a *MismatchError for the first check with an unexpected sum.
+func (cs checks) verify() error {
+	for _, c := range cs {
+		if c.Want == nil {
+			continue
+		}
+		if got := c.Hash.Sum(nil); !bytes.Equal(got, c.Want) {
+			return &MismatchError{Name: c.Name, Want: c.Want, Got: got}
+		}
+	}
+	return nil
+}
+
+// Reader computes the checksums of the data read from an io.Reader.
+type Reader struct {
+	r      io.Reader
+	checks checks
+	err    error // Verification error at EOF
+}
+
+// NewReader returns a reader of r computing the sums of checks. At io.EOF,
+// Read returns a *MismatchError instead if a sum is not the expected one.
+func NewReader(r io.Reader, checks ...Check) *Reader {
+	return &Reader{r: r, checks: checks}
+}
+
+// Sum returns the sum of the data read so far for the check called name, nil
+// if there is no such check.
+func (r *Reader) Sum(name string) []byte {
+	return r.checks.sum(name)
+}
+
+// Read implements io.Reader
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n, err := r.r.Read(p)
+	r.checks.write(p[:n])
+	if err == io.EOF {
+		if verr := r.checks.verify(); verr != nil {
+			r.err = verr
+			return n, verr
+		}
+	}
+	return n, err
+}
+
+// Writer computes the checksums of the data written to an io.Writer.
+type Writer struct {
+	w      io.Writer
+	checks checks
+}
+
+// NewWriter returns a writer to w computing the sums of checks.
+func NewWriter(w io.Writer, checks ...Check) *Writer {
+	return &Writer{w: w, checks: checks}
+}
+
+// Sum returns the sum of the data written so far for the check called name,
+// nil if there is no such check.
+func (w *Writer) Sum(name string) []byte {
+	return w.checks.sum(name)
+}
+
+// Write implements io.Writer, only the bytes written to the underlying writer
+// are hashed.
+func (w *Writer) Write(data []byte) (int, error) {
+	n, err := w.w.Write(data)
+	w.checks.write(data[:n])
+	return n, err
+}
+
+// Verify returns a *MismatchError if a sum of the data written so far is not
+// the expected one.
+func (w *Writer) Verify() error {
+	return w.checks.verify()
+}
+
+// Close closes the underlying writer if it's an io.Closer, and verifies the
+// sums.
+func (w *Writer) Close() error {
+	if c, ok := w.w.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
+	return w.Verify()
+}
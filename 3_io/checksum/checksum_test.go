@@ -0,0 +1,107 @@
+package checksum
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+
+	"goiface/3_io/faultio"
+)
+
+var data = strings.Repeat("Two roads diverged in a yellow wood,\n", 1000)
+
+func sha(s string) []byte {
+	sum := sha256.Sum256([]byte(s))
+	return sum[:]
+}
+
+func TestReader(t *testing.T) {
+	r := NewReader(iotest.HalfReader(strings.NewReader(data)), SHA256(sha(data)), CRC32C(nil), FNV64a(nil))
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, r)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), n)
+	require.Equal(t, data, buf.String())
+
+	require.Equal(t, sha(data), r.Sum("sha256"))
+	sum := crc32.Checksum([]byte(data), castagnoli)
+	require.Equal(t, []byte{byte(sum >> 24), byte(sum >> 16), byte(sum >> 8), byte(sum)}, r.Sum("crc32c"))
+	require.Len(t, r.Sum("fnv64a"), 8)
+	require.Nil(t, r.Sum("md5"))
+}
+
+func TestReader_Mismatch(t *testing.T) {
+	// A single flipped bit
+	fr := faultio.NewReader(strings.NewReader(data), faultio.CorruptAt(1234))
+	r := NewReader(fr, CRC32C(nil), SHA256(sha(data)))
+	_, err := io.Copy(io.Discard, r)
+
+	var merr *MismatchError
+	require.ErrorAs(t, err, &merr)
+	require.Equal(t, "sha256", merr.Name)
+	require.Equal(t, sha(data), merr.Want)
+	require.NotEqual(t, merr.Want, merr.Got)
+	require.Contains(t, err.Error(), "sha256 mismatch")
+
+	// Sticky
+	_, err = r.Read(make([]byte, 10))
+	require.ErrorAs(t, err, &merr)
+
+	// Truncated data doesn't reach EOF
+	tr := faultio.NewReader(strings.NewReader(data), faultio.TruncateAt(100, io.ErrUnexpectedEOF))
+	_, err = io.Copy(io.Discard, NewReader(tr, SHA256(sha(data))))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// Truncated data reaching EOF
+	tr = faultio.NewReader(strings.NewReader(data), faultio.TruncateAt(100, nil))
+	_, err = io.Copy(io.Discard, NewReader(tr, SHA256(sha(data))))
+	require.ErrorAs(t, err, &merr)
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(faultio.NewWriter(&buf, faultio.ShortWrites(7)), SHA256(sha(data)))
+	_, err := io.Copy(w, strings.NewReader(data))
+	require.ErrorIs(t, err, io.ErrShortWrite)
+	// Only written data is hashed
+	require.Equal(t, sha(buf.String()), w.Sum("sha256"))
+
+	buf.Reset()
+	w = NewWriter(&buf, SHA256(sha(data)), FNV64a(nil))
+	_, err = io.Copy(w, strings.NewReader(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Verify())
+
+	w.Write([]byte("more"))
+	var merr *MismatchError
+	require.ErrorAs(t, w.Verify(), &merr)
+}
+
+func TestWriter_Close(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "roads.txt")
+	file, err := os.Create(fileName)
+	require.NoError(t, err)
+
+	w := NewWriter(file, SHA256(sha(data)))
+	_, err = io.WriteString(w, data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Error(t, file.Close(), "closed by w")
+
+	errClose := errors.New("close")
+	w = NewWriter(faultio.NewWriter(io.Discard, faultio.FailClose(errClose)), SHA256(sha(data)))
+	require.ErrorIs(t, w.Close(), errClose)
+
+	w = NewWriter(io.Discard, SHA256(sha(data)))
+	var merr *MismatchError
+	require.ErrorAs(t, w.Close(), &merr)
+}
@@ -0,0 +1,24 @@
+package checksum
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func ExampleReader() {
+	r := NewReader(strings.NewReader("Two roads diverged in a yellow wood,\n"), CRC32C(nil))
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Printf("%x\n", r.Sum("crc32c"))
+
+	r = NewReader(strings.NewReader("And sorry I could not travel both\n"), CRC32C([]byte{1, 2, 3, 4}))
+	_, err := io.Copy(io.Discard, r)
+	fmt.Println(err)
+
+	// Output:
+	// 068d6281
+	// checksum: crc32c mismatch - want 01020304, got 7cf46ad2
+}
@@ -0,0 +1,90 @@
+/*
+Decrypt decrypts a log segment encrypted by crypt.Writer.
+
+Usage:
+
+	decrypt -key FILE [-o OUT] SEGMENT
+
+The key file holds the hex encoded AES key. SEGMENT "-" is the standard
+input. The plain text is printed to the standard output, or written to OUT,
+which is left unchanged if the segment is not authentic.
+*/
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"goiface/3_io/crypt"
+	"goiface/3_io/wfs"
+)
+
+func main() {
+	keyFile := flag.String("key", "", "read the hex encoded key from `FILE`")
+	out := flag.String("o", "", "write the plain text to `FILE`")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [options] SEGMENT\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 || *keyFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*keyFile, flag.Arg(0), *out); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(keyFile, segment, out string) error {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return fmt.Errorf("%s: bad key - %w", keyFile, err)
+	}
+
+	var in io.Reader = os.Stdin
+	if segment != "-" {
+		file, err := os.Open(segment)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	r, err := crypt.NewReader(in, key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", segment, err)
+	}
+
+	if out == "" {
+		if _, err := io.Copy(os.Stdout, r); err != nil {
+			return fmt.Errorf("%s: %w", segment, err)
+		}
+		return nil
+	}
+
+	// Committed only once the whole segment is authenticated
+	f, err := wfs.CreateAtomic(wfs.DirFS(filepath.Dir(out)), filepath.Base(out), 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("%s: %w", segment, err)
+	}
+	return f.Commit()
+}
@@ -0,0 +1,315 @@
+// Package crypt encrypts streams with AES-GCM, in authenticated chunks.
+//
+// The stream starts with a header: the magic "GCM1", the chunk size (a
+// big-endian uint32) and a random 7 bytes nonce prefix. Chunks follow, each is
+// the length of its ciphertext (a big-endian uint32) and the ciphertext of up
+// to chunk size bytes. The nonce of a chunk is the prefix, the chunk number
+// (a big-endian uint32) and 1 for the last chunk, 0 otherwise, and the header
+// is authenticated with every chunk. Chunks can't be modified, reordered,
+// dropped or moved to another stream, and a truncated stream is detected.
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	magic      = "GCM1"
+	prefixSize = 7
+	headerSize = len(magic) + 4 + prefixSize
+
+	// DefaultChunkSize is the default maximal size of a chunk.
+	DefaultChunkSize = 64 << 10
+	maxChunkSize     = 16 << 20
+)
+
+var (
+	// ErrAuth is returned when the data is not authentic: modified, or
+	// encrypted with another key.
+	ErrAuth = errors.New("crypt: message authentication failed")
+	// ErrTruncated is returned when the stream ends before its last chunk.
+	ErrTruncated = errors.New("crypt: truncated stream")
+	// ErrFormat is returned for an invalid header or chunk length.
+	ErrFormat = errors.New("crypt: invalid format")
+)
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("crypt: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// nonce returns the nonce of chunk n.
+func nonce(prefix []byte, n uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[prefixSize:], n)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// Option configures a Writer.
+type Option func(*Writer)
+
+// WithChunkSize sets the maximal size of plain text chunks, the default is
+// DefaultChunkSize. Data is buffered until a chunk is full.
+func WithChunkSize(n int) Option {
+	return func(w *Writer) {
+		w.chunkSize = n
+	}
+}
+
+// Writer encrypts the data written to an io.Writer.
+type Writer struct {
+	w         io.Writer
+	aead      cipher.AEAD
+	chunkSize int
+	header    []byte
+	buf       []byte // Plain text of the current chunk
+	out       []byte // Sealed chunk
+	n         uint32 // Chunk number
+	err       error  // Sticky
+}
+
+// NewWriter returns a writer encrypting data to w with key, an AES key of 16,
+// 24 or 32 bytes. It writes the stream header to w. Close must be called to
+// write the last chunk.
+func NewWriter(w io.Writer, key []byte, opts ...Option) (*Writer, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	cw := Writer{
+		w:         w,
+		aead:      aead,
+		chunkSize: DefaultChunkSize,
+	}
+	for _, opt := range opts {
+		opt(&cw)
+	}
+	if cw.chunkSize <= 0 || cw.chunkSize > maxChunkSize {
+		return nil, fmt.Errorf("crypt: bad chunk size - %d", cw.chunkSize)
+	}
+
+	cw.header = make([]byte, headerSize)
+	copy(cw.header, magic)
+	binary.BigEndian.PutUint32(cw.header[len(magic):], uint32(cw.chunkSize))
+	if _, err := rand.Read(cw.header[len(magic)+4:]); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(cw.header); err != nil {
+		return nil, err
+	}
+
+	cw.buf = make([]byte, 0, cw.chunkSize)
+	return &cw, nil
+}
+
+// Write implements io.Writer
+func (w *Writer) Write(data []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	written := 0
+	for len(data) > 0 {
+		// Seal full chunks only once there's more data, the last chunk is
+		// sealed by Close
+		if len(w.buf) == w.chunkSize {
+			if err := w.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(w.buf[len(w.buf):w.chunkSize], data)
+		w.buf = w.buf[:len(w.buf)+n]
+		data = data[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Flush encrypts and writes the buffered data as a (short) chunk, so it's not
+// lost if the program crashes.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	return w.seal(false)
+}
+
+// seal encrypts and writes the buffer as chunk w.n.
+func (w *Writer) seal(last bool) error {
+	if w.n == 1<<32-1 && !last {
+		w.err = errors.New("crypt: too many chunks")
+		return w.err
+	}
+
+	size := len(w.buf) + w.aead.Overhead()
+	w.out = binary.BigEndian.AppendUint32(w.out[:0], uint32(size))
+	w.out = w.aead.Seal(w.out, nonce(w.header[len(magic)+4:], w.n, last), w.buf, w.header)
+	if _, err := w.w.Write(w.out); err != nil {
+		w.err = err
+		return err
+	}
+
+	w.n++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+var errClosed = errors.New("crypt: writer closed")
+
+// Close writes the last chunk, and closes the underlying writer if it's an
+// io.Closer, even after an error.
+func (w *Writer) Close() error {
+	if w.err == errClosed {
+		return errClosed
+	}
+
+	err := w.err
+	if err == nil {
+		err = w.seal(true)
+	}
+	w.err = errClosed
+	if c, ok := w.w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Reader decrypts the data read from an io.Reader.
+type Reader struct {
+	r         io.Reader
+	aead      cipher.AEAD
+	header    []byte
+	chunkSize int
+	buf       []byte // Chunk ciphertext
+	dec       []byte // Chunk plain text
+	plain     []byte // Decrypted data not read yet
+	n         uint32
+	last      bool // Last chunk decrypted
+	err       error
+}
+
+// NewReader returns a reader decrypting the stream in r with key. It reads
+// the stream header.
+func NewReader(r io.Reader, key []byte) (*Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("%w - short header", ErrFormat)
+		}
+		return nil, err
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, fmt.Errorf("%w - bad magic", ErrFormat)
+	}
+	chunkSize := int(binary.BigEndian.Uint32(header[len(magic):]))
+	if chunkSize <= 0 || chunkSize > maxChunkSize {
+		return nil, fmt.Errorf("%w - bad chunk size %d", ErrFormat, chunkSize)
+	}
+
+	cr := Reader{
+		r:         r,
+		aead:      aead,
+		header:    header,
+		chunkSize: chunkSize,
+	}
+	return &cr, nil
+}
+
+// Read implements io.Reader. It returns ErrAuth if a chunk is not authentic,
+// and ErrTruncated if the stream ends before its last chunk. Data is returned
+// only once its chunk is authenticated.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.last {
+			r.err = r.checkEnd()
+			continue
+		}
+		r.err = r.open()
+	}
+
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+// open reads and decrypts the next chunk.
+func (r *Reader) open() error {
+	var hdr [4]byte
+	if _, err := io.ReadFull(r.r, hdr[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrTruncated
+		}
+		return err
+	}
+	size := int(binary.BigEndian.Uint32(hdr[:]))
+	if size < r.aead.Overhead() || size > r.chunkSize+r.aead.Overhead() {
+		return fmt.Errorf("%w - bad chunk length %d", ErrFormat, size)
+	}
+
+	if cap(r.buf) < size {
+		r.buf = make([]byte, size)
+	}
+	r.buf = r.buf[:size]
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrTruncated
+		}
+		return err
+	}
+
+	prefix := r.header[len(magic)+4:]
+	// Open clears dst on failure, it can't decrypt in place
+	plain, err := r.aead.Open(r.dec[:0], nonce(prefix, r.n, false), r.buf, r.header)
+	if err != nil {
+		plain, err = r.aead.Open(r.dec[:0], nonce(prefix, r.n, true), r.buf, r.header)
+		if err != nil {
+			return ErrAuth
+		}
+		r.last = true
+	}
+
+	r.n++
+	r.dec, r.plain = plain, plain
+	return nil
+}
+
+// checkEnd returns io.EOF if there's no data after the last chunk.
+func (r *Reader) checkEnd() error {
+	var b [1]byte
+	n, err := io.ReadFull(r.r, b[:])
+	switch {
+	case n > 0:
+		return fmt.Errorf("%w - data after last chunk", ErrFormat)
+	case err == io.EOF:
+		return io.EOF
+	}
+	return err
+}
@@ -0,0 +1,203 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+
+	"goiface/3_io/faultio"
+)
+
+var key = bytes.Repeat([]byte{0x42}, 32)
+
+// encrypt returns data encrypted with key, flushed every flush bytes if > 0.
+func encrypt(t *testing.T, data []byte, flush int, opts ...Option) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, key, opts...)
+	require.NoError(t, err)
+
+	for len(data) > 0 {
+		n := len(data)
+		if flush > 0 {
+			n = min(n, flush)
+		}
+		_, err := w.Write(data[:n])
+		require.NoError(t, err)
+		if flush > 0 {
+			require.NoError(t, w.Flush())
+		}
+		data = data[n:]
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func decrypt(data []byte, key []byte) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(data), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(iotest.HalfReader(r))
+}
+
+// flip returns a copy of data with the bits of the byte at off flipped.
+func flip(data []byte, off int) []byte {
+	data = bytes.Clone(data)
+	data[off] ^= 0xff
+	return data
+}
+
+// chunks splits an encrypted stream in its header and chunks.
+func chunks(data []byte) ([]byte, [][]byte) {
+	header, data := data[:headerSize], data[headerSize:]
+	var out [][]byte
+	for len(data) > 0 {
+		size := 4 + int(binary.BigEndian.Uint32(data))
+		out = append(out, data[:size])
+		data = data[size:]
+	}
+	return header, out
+}
+
+func TestRoundTrip(t *testing.T) {
+	text := []byte(strings.Repeat("info: Go Rocks!\n", 1000))
+
+	testCases := []struct {
+		name      string
+		data      []byte
+		chunkSize int
+		flush     int
+		chunks    int
+	}{
+		{"empty", nil, 0, 0, 1},
+		{"default", text, 0, 0, 1},
+		{"full chunks", text, 1000, 0, 16},
+		{"partial chunk", text, 3000, 0, 6},
+		{"one byte chunks", text[:100], 1, 0, 100},
+		{"flush", text, 1000, 700, 24},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var opts []Option
+			if tc.chunkSize > 0 {
+				opts = append(opts, WithChunkSize(tc.chunkSize))
+			}
+			data := encrypt(t, tc.data, tc.flush, opts...)
+			_, cs := chunks(data)
+			require.Equal(t, tc.chunks, len(cs))
+			require.NotContains(t, string(data), "Go Rocks!")
+
+			plain, err := decrypt(data, key)
+			require.NoError(t, err)
+			require.Equal(t, len(tc.data), len(plain))
+			require.True(t, bytes.Equal(tc.data, plain))
+		})
+	}
+}
+
+func TestNonce(t *testing.T) {
+	// The same data encrypts differently with a new random prefix
+	data := []byte("Go Rocks!\n")
+	require.NotEqual(t, encrypt(t, data, 0), encrypt(t, data, 0))
+}
+
+func TestReader_Errors(t *testing.T) {
+	data := encrypt(t, []byte(strings.Repeat("Go Rocks!\n", 10)), 0, WithChunkSize(30))
+	header, cs := chunks(data)
+	require.Len(t, cs, 4)
+	join := func(header []byte, cs ...[]byte) []byte {
+		return bytes.Join(append([][]byte{header}, cs...), nil)
+	}
+	other := encrypt(t, []byte(strings.Repeat("Go Rocks!\n", 10)), 0, WithChunkSize(30))
+	_, otherChunks := chunks(other)
+	badMagic := bytes.Clone(header)
+	badMagic[0] = 'X'
+	badSize := bytes.Clone(header)
+	binary.BigEndian.PutUint32(badSize[4:], 0)
+	badLength := binary.BigEndian.AppendUint32(nil, 1)
+
+	testCases := []struct {
+		name string
+		data []byte
+		key  []byte
+		err  error
+	}{
+		{"wrong key", data, bytes.Repeat([]byte{1}, 32), ErrAuth},
+		{"modified", flip(data, headerSize+10), key, ErrAuth},
+		{"modified header", flip(data, headerSize-1), key, ErrAuth},
+		{"reordered", join(header, cs[1], cs[0], cs[2], cs[3]), key, ErrAuth},
+		{"dropped", join(header, cs[0], cs[2], cs[3]), key, ErrAuth},
+		{"other stream", join(header, cs[0], otherChunks[1], cs[2], cs[3]), key, ErrAuth},
+		{"last chunk dropped", join(header, cs[:3]...), key, ErrTruncated},
+		{"cut chunk", data[:len(data)-5], key, ErrTruncated},
+		{"header only", header, key, ErrTruncated},
+		{"short header", header[:5], key, ErrFormat},
+		{"bad magic", join(badMagic, cs...), key, ErrFormat},
+		{"bad chunk size", join(badSize, cs...), key, ErrFormat},
+		{"bad chunk length", join(header, cs[0], badLength), key, ErrFormat},
+		{"trailing data", append(bytes.Clone(data), '\n'), key, ErrFormat},
+		{"after last chunk", join(header, cs[0], cs[1], cs[2], cs[3], cs[0]), key, ErrFormat},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := decrypt(tc.data, tc.key)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestReader_Partial(t *testing.T) {
+	// Authenticated chunks are returned before the error
+	data := encrypt(t, []byte(strings.Repeat("Go Rocks!\n", 10)), 0, WithChunkSize(30))
+	r, err := NewReader(bytes.NewReader(data[:len(data)-5]), key)
+	require.NoError(t, err)
+	plain, err := io.ReadAll(r)
+	require.ErrorIs(t, err, ErrTruncated)
+	require.Equal(t, strings.Repeat("Go Rocks!\n", 9), string(plain))
+
+	_, err = r.Read(make([]byte, 10))
+	require.ErrorIs(t, err, ErrTruncated, "sticky")
+}
+
+func TestNewWriter_Errors(t *testing.T) {
+	_, err := NewWriter(io.Discard, []byte("short"))
+	require.Error(t, err)
+	_, err = NewReader(bytes.NewReader(nil), []byte("short"))
+	require.Error(t, err)
+
+	for _, size := range []int{-1, 0, maxChunkSize + 1} {
+		_, err = NewWriter(io.Discard, key, WithChunkSize(size))
+		require.Error(t, err, "chunk size %d", size)
+	}
+
+	_, err = NewWriter(faultio.NewWriter(io.Discard, faultio.FailAfter(0, nil)), key)
+	require.ErrorIs(t, err, faultio.ErrInjected)
+}
+
+func TestWriter_Faults(t *testing.T) {
+	fw := faultio.NewWriter(io.Discard, faultio.FailAfter(100, nil), faultio.FailClose(io.ErrClosedPipe))
+	w, err := NewWriter(fw, key, WithChunkSize(50))
+	require.NoError(t, err)
+
+	data := []byte(strings.Repeat("Go Rocks!\n", 20))
+	_, err = w.Write(data)
+	require.ErrorIs(t, err, faultio.ErrInjected)
+	_, err = w.Write(data)
+	require.ErrorIs(t, err, faultio.ErrInjected, "sticky")
+	require.ErrorIs(t, w.Flush(), faultio.ErrInjected)
+
+	// Close still closes the underlying writer
+	require.ErrorIs(t, w.Close(), faultio.ErrInjected)
+	require.Error(t, w.Close())
+
+	w, err = NewWriter(faultio.NewWriter(io.Discard, faultio.FailClose(nil)), key)
+	require.NoError(t, err)
+	require.ErrorIs(t, w.Close(), faultio.ErrInjected)
+}
@@ -0,0 +1,38 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+)
+
+func ExampleWriter() {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, key)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Fprintln(w, "info: Go Rocks!")
+	if err := w.Close(); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+
+	r, err := NewReader(&buf, key)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	if _, err := io.Copy(os.Stdout, r); err != nil {
+		fmt.Println("error:", err)
+	}
+
+	// Output:
+	// info: Go Rocks!
+}
@@ -0,0 +1,221 @@
+// Package faultio implements readers and writers with injected faults, for
+// testing I/O error paths. See also testing/iotest.
+package faultio
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrInjected is the default injected error.
+var ErrInjected = errors.New("faultio: injected error")
+
+// WriterFault configures a Writer fault.
+type WriterFault func(*Writer)
+
+// ShortWrites makes every Write write at most n bytes and return a nil error,
+// breaking the io.Writer contract.
+func ShortWrites(n int) WriterFault {
+	return func(w *Writer) {
+		w.maxWrite = n
+	}
+}
+
+// FailAfter makes writes fail with err once n bytes were written, err
+// defaults to ErrInjected.
+func FailAfter(n int64, err error) WriterFault {
+	return func(w *Writer) {
+		w.failAfter = n
+		w.writeErr = orDefault(err)
+	}
+}
+
+// FailSync makes Sync return err, err defaults to ErrInjected.
+func FailSync(err error) WriterFault {
+	return func(w *Writer) {
+		w.syncErr = orDefault(err)
+	}
+}
+
+// FailClose makes Close return err, err defaults to ErrInjected.
+func FailClose(err error) WriterFault {
+	return func(w *Writer) {
+		w.closeErr = orDefault(err)
+	}
+}
+
+// Latency makes every Write sleep for d first.
+func Latency(d time.Duration) WriterFault {
+	return func(w *Writer) {
+		w.latency = d
+	}
+}
+
+func orDefault(err error) error {
+	if err == nil {
+		return ErrInjected
+	}
+	return err
+}
+
+// Writer is an io.Writer with injected faults.
+// It implements Sync and Close, calling the underlying writer methods if it
+// has them.
+type Writer struct {
+	w         io.Writer
+	maxWrite  int
+	failAfter int64 // -1 is never
+	writeErr  error
+	syncErr   error
+	closeErr  error
+	latency   time.Duration
+	written   int64
+}
+
+// NewWriter returns a writer writing to w with faults.
+func NewWriter(w io.Writer, faults ...WriterFault) *Writer {
+	fw := Writer{
+		w:         w,
+		failAfter: -1,
+	}
+	for _, f := range faults {
+		f(&fw)
+	}
+
+	return &fw
+}
+
+// Write implements io.Writer
+func (w *Writer) Write(data []byte) (int, error) {
+	if w.latency > 0 {
+		time.Sleep(w.latency)
+	}
+
+	var err error
+	if w.failAfter >= 0 {
+		left := w.failAfter - w.written
+		if left <= 0 {
+			return 0, w.writeErr
+		}
+		if int64(len(data)) > left {
+			data, err = data[:left], w.writeErr
+		}
+	}
+
+	if w.maxWrite > 0 && len(data) > w.maxWrite {
+		data, err = data[:w.maxWrite], nil
+	}
+
+	n, werr := w.w.Write(data)
+	w.written += int64(n)
+	if werr != nil {
+		return n, werr
+	}
+
+	return n, err
+}
+
+// Written returns the number of bytes written.
+func (w *Writer) Written() int64 {
+	return w.written
+}
+
+type syncer interface {
+	Sync() error
+}
+
+// Sync returns the injected error or calls the underlying Sync.
+func (w *Writer) Sync() error {
+	if w.syncErr != nil {
+		return w.syncErr
+	}
+
+	if s, ok := w.w.(syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close calls the underlying Close and returns the injected error if set.
+func (w *Writer) Close() error {
+	var err error
+	if c, ok := w.w.(io.Closer); ok {
+		err = c.Close()
+	}
+
+	if w.closeErr != nil {
+		return w.closeErr
+	}
+	return err
+}
+
+// ReaderFault configures a Reader fault.
+type ReaderFault func(*Reader)
+
+// CorruptAt flips the bits of the bytes at offsets.
+func CorruptAt(offsets ...int64) ReaderFault {
+	return func(r *Reader) {
+		for _, off := range offsets {
+			r.corrupt[off] = true
+		}
+	}
+}
+
+// TruncateAt ends the data after n bytes with err, err defaults to io.EOF.
+// Use io.ErrUnexpectedEOF or ErrInjected to simulate a broken stream.
+func TruncateAt(n int64, err error) ReaderFault {
+	return func(r *Reader) {
+		if err == nil {
+			err = io.EOF
+		}
+		r.truncate = n
+		r.truncErr = err
+	}
+}
+
+// Reader is an io.Reader with injected faults.
+type Reader struct {
+	r        io.Reader
+	corrupt  map[int64]bool
+	truncate int64 // -1 is never
+	truncErr error
+	offset   int64
+}
+
+// NewReader returns a reader reading from r with faults.
+func NewReader(r io.Reader, faults ...ReaderFault) *Reader {
+	fr := Reader{
+		r:        r,
+		corrupt:  make(map[int64]bool),
+		truncate: -1,
+	}
+	for _, f := range faults {
+		f(&fr)
+	}
+
+	return &fr
+}
+
+// Read implements io.Reader
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.truncate >= 0 {
+		left := r.truncate - r.offset
+		if left <= 0 {
+			return 0, r.truncErr
+		}
+		if int64(len(p)) > left {
+			p = p[:left]
+		}
+	}
+
+	n, err := r.r.Read(p)
+	for i := 0; i < n; i++ {
+		if r.corrupt[r.offset+int64(i)] {
+			p[i] ^= 0xFF
+		}
+	}
+	r.offset += int64(n)
+
+	return n, err
+}
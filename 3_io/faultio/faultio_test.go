@@ -0,0 +1,62 @@
+package faultio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter_ShortWrites(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, ShortWrites(3))
+
+	n, err := w.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, "hel", buf.String())
+}
+
+func TestWriter_FailAfter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, FailAfter(7, nil))
+
+	n, err := w.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	n, err = w.Write([]byte("world"))
+	require.ErrorIs(t, err, ErrInjected)
+	require.Equal(t, 2, n)
+
+	_, err = w.Write([]byte("!"))
+	require.ErrorIs(t, err, ErrInjected)
+	require.Equal(t, "hellowo", buf.String())
+	require.Equal(t, int64(7), w.Written())
+}
+
+func TestWriter_SyncClose(t *testing.T) {
+	errDisk := errors.New("disk full")
+	w := NewWriter(io.Discard, FailSync(errDisk), FailClose(nil))
+	require.ErrorIs(t, w.Sync(), errDisk)
+	require.ErrorIs(t, w.Close(), ErrInjected)
+
+	w = NewWriter(io.Discard)
+	require.NoError(t, w.Sync())
+	require.NoError(t, w.Close())
+}
+
+func TestReader(t *testing.T) {
+	r := NewReader(strings.NewReader("hello world"), CorruptAt(0, 4), TruncateAt(8, io.ErrUnexpectedEOF))
+	data, err := io.ReadAll(r)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, []byte{'h' ^ 0xFF, 'e', 'l', 'l', 'o' ^ 0xFF, ' ', 'w', 'o'}, data)
+
+	r = NewReader(strings.NewReader("hello"), TruncateAt(3, nil))
+	data, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "hel", string(data))
+}
@@ -0,0 +1,145 @@
+/*
+Grep prints lines matching a pattern in plain, gzip or bzip2 files.
+
+Usage:
+
+	grep [-F] [-i] [-v] [-c] [-n] [-A N] [-B N] [-C N] [-j N] PATTERN [FILE...]
+
+It reads the standard input if there are no files. Compressed files are
+detected from their content. Files are searched in parallel (-j), and
+printed in order.
+
+The exit code is 0 if a line was selected, 1 if not and 2 on error.
+*/
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"goiface/3_io/grep"
+)
+
+func main() {
+	fixed := flag.Bool("F", false, "pattern is a fixed string")
+	ignoreCase := flag.Bool("i", false, "ignore case")
+	invert := flag.Bool("v", false, "select non matching lines")
+	count := flag.Bool("c", false, "only print the number of selected lines")
+	lineNums := flag.Bool("n", false, "print line numbers")
+	after := flag.Int("A", 0, "print `N` lines after a match")
+	before := flag.Int("B", 0, "print `N` lines before a match")
+	around := flag.Int("C", 0, "print `N` lines before and after a match")
+	workers := flag.Int("j", runtime.NumCPU(), "search `N` files in parallel")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [options] PATTERN [FILE...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := []grep.Option{
+		grep.Context(max(*before, *around), max(*after, *around)),
+		grep.Workers(*workers),
+	}
+	if *fixed {
+		opts = append(opts, grep.Fixed())
+	}
+	if *ignoreCase {
+		opts = append(opts, grep.IgnoreCase())
+	}
+	if *invert {
+		opts = append(opts, grep.Invert())
+	}
+	if *count {
+		opts = append(opts, grep.CountOnly())
+	}
+
+	s, err := grep.New(flag.Arg(0), opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(2)
+	}
+
+	files := flag.Args()[1:]
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	p := printer{
+		w:        w,
+		names:    len(files) > 1,
+		lineNums: *lineNums,
+		groups:   max(*before, *after, *around) > 0,
+	}
+	results, err := s.SearchFiles(context.Background(), files, p.print)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(2)
+	}
+
+	code := 1
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "error: %q - %s\n", r.Name, r.Err)
+			code = 2
+			continue
+		}
+
+		if *count {
+			if p.names {
+				fmt.Fprintf(w, "%s:", r.Name)
+			}
+			fmt.Fprintf(w, "%d\n", r.Count)
+		}
+		if r.Count > 0 && code == 1 {
+			code = 0
+		}
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(2)
+	}
+	os.Exit(code)
+}
+
+// printer prints matches like grep. With context lines, it prints a "--"
+// line between non adjacent groups of lines.
+type printer struct {
+	w        io.Writer
+	names    bool
+	lineNums bool
+	groups   bool // Separate groups of context lines
+
+	name string
+	last int64
+}
+
+func (p *printer) print(m grep.Match) error {
+	if p.groups && p.last > 0 && (m.Name != p.name || m.Line != p.last+1) {
+		fmt.Fprintln(p.w, "--")
+	}
+	p.name, p.last = m.Name, m.Line
+
+	sep := ":"
+	if m.Context {
+		sep = "-"
+	}
+	if p.names {
+		fmt.Fprintf(p.w, "%s%s", m.Name, sep)
+	}
+	if p.lineNums {
+		fmt.Fprintf(p.w, "%d%s", m.Line, sep)
+	}
+	_, err := fmt.Fprintf(p.w, "%s\n", m.Text)
+	return err
+}
@@ -0,0 +1,27 @@
+package grep
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+)
+
+// Decompress returns a reader of the decompressed data in r if it starts with
+// a gzip or bzip2 header, otherwise a reader of r as is. The format is
+// detected from the data, not from a file name.
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	hdr, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case len(hdr) >= 2 && hdr[0] == 0x1f && hdr[1] == 0x8b:
+		return gzip.NewReader(br)
+	case len(hdr) == 4 && string(hdr[:3]) == "BZh" && hdr[3] >= '1' && hdr[3] <= '9':
+		return bzip2.NewReader(br), nil
+	}
+	return br, nil
+}
@@ -0,0 +1,173 @@
+package grep
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// Result is the result of searching a file.
+type Result struct {
+	Name  string
+	Count int64 // Selected lines
+	Err   error // Error opening or reading the file
+}
+
+// SearchFile searches the file fileName, decompressing it if needed. The
+// file name "-" is the standard input.
+func (s *Searcher) SearchFile(fileName string, fn func(Match) error) (int64, error) {
+	return s.searchFile(context.Background(), fileName, fn)
+}
+
+// searchFile is SearchFile returning ctx.Err() once ctx is done, even if a read
+// is blocked.
+func (s *Searcher) searchFile(ctx context.Context, fileName string, fn func(Match) error) (int64, error) {
+	var r io.Reader = os.Stdin
+	if fileName != "-" {
+		file, err := os.Open(fileName)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+		r = file
+	}
+	if ctx.Done() != nil {
+		r = &ctxReader{ctx: ctx, r: r}
+	}
+
+	r, err := Decompress(r)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.Search(r, func(m Match) error {
+		m.Name = fileName
+		return fn(m)
+	})
+}
+
+// SearchFiles searches files with the Workers option number of goroutines.
+// It calls fn from the calling goroutine, with the matches of the first file,
+// then of the second and so on. Workers ahead of the file being reported
+// block once they have a few matches pending.
+//
+// Errors opening or reading a file are in its Result and don't stop the
+// search. SearchFiles stops at the first error from fn, or when ctx is done,
+// and returns it.
+func (s *Searcher) SearchFiles(ctx context.Context, files []string, fn func(Match) error) ([]Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	type job struct {
+		matches chan Match
+		result  Result
+	}
+	jobs := make([]*job, len(files))
+	for i, name := range files {
+		jobs[i] = &job{
+			matches: make(chan Match, 64),
+			result:  Result{Name: name},
+		}
+	}
+
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// Start workers in file order, so the file being reported always has one
+	sem := make(chan struct{}, s.workers)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, j := range jobs {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				defer close(j.matches)
+
+				j.result.Count, j.result.Err = s.searchFile(ctx, j.result.Name, func(m Match) error {
+					m.Text = append([]byte(nil), m.Text...)
+					select {
+					case j.matches <- m:
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				})
+			}()
+		}
+	}()
+
+	results := make([]Result, 0, len(files))
+	for _, j := range jobs {
+		if err := report(ctx, j.matches, fn); err != nil {
+			return results, err
+		}
+		results = append(results, j.result)
+	}
+	return results, nil
+}
+
+// ctxReader is a reader returning ctx.Err() once ctx is done. Reads are done in
+// a goroutine, a blocked read (e.g. on a terminal) is abandoned.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+	buf []byte
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+// Read implements io.Reader
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	// The goroutine owns buf until it sends, it's not reused after ctx is done
+	if cap(r.buf) < len(p) {
+		r.buf = make([]byte, len(p))
+	}
+	buf := r.buf[:len(p)]
+	ch := make(chan readResult, 1)
+	go func() {
+		n, err := r.r.Read(buf)
+		ch <- readResult{n, err}
+	}()
+
+	select {
+	case res := <-ch:
+		return copy(p, buf[:res.n]), res.err
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	}
+}
+
+// report calls fn with the matches from ch until it's closed.
+func report(ctx context.Context, ch <-chan Match, fn func(Match) error) error {
+	for {
+		select {
+		case m, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := fn(m); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Package grep searches lines in plain or compressed text.
+//
+// A Searcher reads its input one line at a time and keeps only the context
+// lines before a match, memory does not grow with the size of the input.
+// SearchFiles searches several files in parallel and reports matches in file
+// order.
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// Match is a matching line or a context line.
+type Match struct {
+	Name    string // File name, set by SearchFiles
+	Line    int64  // Line number, starting at 1
+	Text    []byte // Line without the trailing newline
+	Context bool   // Context line around a match
+}
+
+// Option configures a Searcher.
+type Option func(*Searcher)
+
+// Fixed matches the pattern as a fixed string instead of a regular
+// expression.
+func Fixed() Option {
+	return func(s *Searcher) {
+		s.fixed = true
+	}
+}
+
+// IgnoreCase matches the pattern case insensitively.
+func IgnoreCase() Option {
+	return func(s *Searcher) {
+		s.ignoreCase = true
+	}
+}
+
+// Invert selects the lines which do not match the pattern.
+func Invert() Option {
+	return func(s *Searcher) {
+		s.invert = true
+	}
+}
+
+// Context reports up to before lines before and after lines after every
+// match.
+func Context(before, after int) Option {
+	return func(s *Searcher) {
+		s.before, s.after = max(before, 0), max(after, 0)
+	}
+}
+
+// CountOnly only counts the selected lines, Search does not report them.
+func CountOnly() Option {
+	return func(s *Searcher) {
+		s.countOnly = true
+	}
+}
+
+// Workers sets how many files SearchFiles searches in parallel, the default
+// is 1.
+func Workers(n int) Option {
+	return func(s *Searcher) {
+		s.workers = max(n, 1)
+	}
+}
+
+// Searcher selects lines matching a pattern. It is safe for concurrent use.
+type Searcher struct {
+	match func([]byte) bool
+
+	fixed      bool
+	ignoreCase bool
+	invert     bool
+	before     int
+	after      int
+	countOnly  bool
+	workers    int
+}
+
+// New returns a Searcher for pattern, a regular expression in RE2 syntax
+// unless the Fixed option is used.
+func New(pattern string, opts ...Option) (*Searcher, error) {
+	s := Searcher{workers: 1}
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	if s.fixed && !s.ignoreCase {
+		needle := []byte(pattern)
+		s.match = func(line []byte) bool { return bytes.Contains(line, needle) }
+		return &s, nil
+	}
+
+	if s.fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if s.ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("grep: %w", err)
+	}
+	s.match = re.Match
+	return &s, nil
+}
+
+// Search calls fn with the selected lines in r, and their context lines, in
+// order. The Text of a Match is valid only until fn returns. Search stops at
+// the first error from fn and returns it. It returns the number of selected
+// lines.
+func (s *Searcher) Search(r io.Reader, fn func(Match) error) (int64, error) {
+	lr := lineReader{r: bufio.NewReaderSize(r, 64<<10)}
+	before := newRing(s.before)
+	var (
+		count int64
+		after int   // Context lines left to report after a match
+		last  int64 // Last reported line
+	)
+
+	for n := int64(1); ; n++ {
+		line, err := lr.next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		if s.match(line) == s.invert {
+			if after > 0 {
+				after--
+				last = n
+				if err := fn(Match{Line: n, Text: line, Context: true}); err != nil {
+					return count, err
+				}
+			} else if !s.countOnly {
+				before.push(n, line)
+			}
+			continue
+		}
+
+		count++
+		if s.countOnly {
+			continue
+		}
+
+		for _, m := range before.lines() {
+			if m.Line > last {
+				if err := fn(m); err != nil {
+					return count, err
+				}
+			}
+		}
+		before.reset()
+
+		last, after = n, s.after
+		if err := fn(Match{Line: n, Text: line}); err != nil {
+			return count, err
+		}
+	}
+}
+
+// lineReader reads lines without their newline, reusing its buffer for lines
+// longer than the bufio.Reader buffer.
+type lineReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// next returns the next line, valid until the following call.
+func (lr *lineReader) next() ([]byte, error) {
+	line, err := lr.r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		lr.buf = append(lr.buf[:0], line...)
+		for err == bufio.ErrBufferFull {
+			line, err = lr.r.ReadSlice('\n')
+			lr.buf = append(lr.buf, line...)
+		}
+		line = lr.buf
+	}
+
+	switch {
+	case err == io.EOF && len(line) > 0: // Last line without newline
+		return line, nil
+	case err != nil:
+		return nil, err
+	}
+	return line[:len(line)-1], nil
+}
+
+// ring keeps copies of the last size lines.
+type ring struct {
+	buf   []Match
+	start int
+	n     int
+}
+
+func newRing(size int) *ring {
+	return &ring{buf: make([]Match, size)}
+}
+
+func (r *ring) push(n int64, text []byte) {
+	if len(r.buf) == 0 {
+		return
+	}
+
+	i := (r.start + r.n) % len(r.buf)
+	if r.n == len(r.buf) {
+		r.start = (r.start + 1) % len(r.buf)
+	} else {
+		r.n++
+	}
+	m := &r.buf[i]
+	m.Line, m.Text, m.Context = n, append(m.Text[:0], text...), true
+}
+
+// lines returns the lines in order, they are valid until the next push.
+func (r *ring) lines() []Match {
+	out := make([]Match, 0, r.n)
+	for i := 0; i < r.n; i++ {
+		out = append(out, r.buf[(r.start+i)%len(r.buf)])
+	}
+	return out
+}
+
+func (r *ring) reset() {
+	r.start, r.n = 0, 0
+}
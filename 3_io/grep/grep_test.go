@@ -0,0 +1,252 @@
+package grep
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goiface/3_io/faultio"
+)
+
+var roads = filepath.Join("..", "challenge", "roads.txt.gz")
+
+// search returns the matches in data formatted like grep -n.
+func search(t *testing.T, s *Searcher, data string) []string {
+	var out []string
+	_, err := s.Search(strings.NewReader(data), func(m Match) error {
+		sep := ":"
+		if m.Context {
+			sep = "-"
+		}
+		out = append(out, fmt.Sprintf("%d%s%s", m.Line, sep, m.Text))
+		return nil
+	})
+	require.NoError(t, err)
+	return out
+}
+
+func TestSearch(t *testing.T) {
+	data := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten"
+
+	testCases := []struct {
+		name    string
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{"regexp", "^t", nil, []string{"2:two", "3:three", "10:ten"}},
+		{"fixed", "e.", []Option{Fixed()}, nil},
+		{"fixed match", "ve", []Option{Fixed()}, []string{"5:five", "7:seven"}},
+		{"ignore case", "T", []Option{IgnoreCase()}, []string{"2:two", "3:three", "8:eight", "10:ten"}},
+		{"fixed ignore case", "E.", []Option{Fixed(), IgnoreCase()}, nil},
+		{"invert", "e", []Option{Invert()}, []string{"2:two", "4:four", "6:six"}},
+		{"last line", "ten", nil, []string{"10:ten"}},
+		{
+			"context", "four|six", []Option{Context(1, 1)},
+			[]string{"3-three", "4:four", "5-five", "6:six", "7-seven"},
+		},
+		{
+			"before", "six", []Option{Context(3, 0)},
+			[]string{"3-three", "4-four", "5-five", "6:six"},
+		},
+		{
+			"groups", "two|nine", []Option{Context(2, 1)},
+			[]string{"1-one", "2:two", "3-three", "7-seven", "8-eight", "9:nine", "10-ten"},
+		},
+		{
+			"after overlaps", "one|two|four", []Option{Context(0, 2)},
+			[]string{"1:one", "2:two", "3-three", "4:four", "5-five", "6-six"},
+		},
+		{"start", "one", []Option{Context(5, 0)}, []string{"1:one"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(tc.pattern, tc.opts...)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, search(t, s, data))
+		})
+	}
+}
+
+func TestSearch_CountOnly(t *testing.T) {
+	s, err := New("and", IgnoreCase(), CountOnly(), Context(2, 2))
+	require.NoError(t, err)
+
+	file, err := os.Open(roads)
+	require.NoError(t, err)
+	defer file.Close()
+	r, err := Decompress(file)
+	require.NoError(t, err)
+
+	n, err := s.Search(r, func(m Match) error {
+		t.Fatalf("called with %v", m)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(9), n)
+}
+
+func TestSearch_LongLines(t *testing.T) {
+	long := strings.Repeat("x", 200_000)
+	data := "a\n" + long + "y\nb" + long + "\n"
+
+	s, err := New("y$")
+	require.NoError(t, err)
+	var lines []int64
+	n, err := s.Search(strings.NewReader(data), func(m Match) error {
+		lines = append(lines, m.Line)
+		require.Len(t, m.Text, len(long)+1)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+	require.Equal(t, []int64{2}, lines)
+}
+
+func TestSearch_Errors(t *testing.T) {
+	_, err := New("(")
+	require.Error(t, err)
+
+	s, err := New("o")
+	require.NoError(t, err)
+
+	r := faultio.NewReader(strings.NewReader("one\ntwo\nthree\n"), faultio.TruncateAt(6, faultio.ErrInjected))
+	_, err = s.Search(r, func(Match) error { return nil })
+	require.ErrorIs(t, err, faultio.ErrInjected)
+
+	errStop := errors.New("stop")
+	n, err := s.Search(strings.NewReader("one\ntwo\nthree\n"), func(Match) error { return errStop })
+	require.ErrorIs(t, err, errStop)
+	require.Equal(t, int64(1), n)
+}
+
+func TestDecompress(t *testing.T) {
+	want := "Two roads diverged in a yellow wood,"
+	for _, fileName := range []string{roads, filepath.Join("testdata", "roads.txt.bz2")} {
+		file, err := os.Open(fileName)
+		require.NoError(t, err)
+		r, err := Decompress(file)
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		file.Close()
+		require.NoError(t, err)
+		require.Equal(t, want, strings.SplitN(string(data), "\n", 2)[0])
+	}
+
+	// Plain text, including short and almost compressed headers
+	for _, data := range []string{"", "B", "BZh", "BZhx plain", want} {
+		r, err := Decompress(strings.NewReader(data))
+		require.NoError(t, err)
+		out, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, data, string(out))
+	}
+
+	_, err := Decompress(faultio.NewReader(strings.NewReader(want), faultio.TruncateAt(0, faultio.ErrInjected)))
+	require.ErrorIs(t, err, faultio.ErrInjected)
+}
+
+func writeGzip(t *testing.T, fileName, data string) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, os.WriteFile(fileName, buf.Bytes(), 0666))
+}
+
+func TestSearchFiles(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	var want []string
+	for i := 0; i < 20; i++ {
+		fileName := filepath.Join(dir, fmt.Sprintf("%02d.txt", i))
+		var sb strings.Builder
+		for n := 1; n <= 500; n++ {
+			fmt.Fprintf(&sb, "file %d line %d\n", i, n)
+			if n%50 == 0 {
+				want = append(want, fmt.Sprintf("%s:%d", fileName, n))
+			}
+		}
+		if i%2 == 0 {
+			writeGzip(t, fileName, sb.String())
+		} else {
+			require.NoError(t, os.WriteFile(fileName, []byte(sb.String()), 0666))
+		}
+		files = append(files, fileName)
+	}
+	files = append(files, filepath.Join(dir, "missing.txt"))
+
+	s, err := New("line [0-9]*[05]0$", Workers(4))
+	require.NoError(t, err)
+	var got []string
+	results, err := s.SearchFiles(context.Background(), files, func(m Match) error {
+		got = append(got, fmt.Sprintf("%s:%d", m.Name, m.Line))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	require.Len(t, results, len(files))
+	for i, r := range results[:len(results)-1] {
+		require.Equal(t, files[i], r.Name)
+		require.NoError(t, r.Err)
+		require.Equal(t, int64(10), r.Count)
+	}
+	require.ErrorIs(t, results[len(results)-1].Err, os.ErrNotExist)
+
+	// Stop early
+	errStop := errors.New("stop")
+	results, err = s.SearchFiles(context.Background(), files, func(m Match) error {
+		if m.Name == files[3] {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+	require.Len(t, results, 3)
+}
+
+func TestSearchFiles_Cancel(t *testing.T) {
+	// The standard input is a pipe which is never closed
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer w.Close()
+	defer r.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+	_, err = io.WriteString(w, "Go Rocks!\n")
+	require.NoError(t, err)
+
+	s, err := New("Go", Workers(2))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.SearchFiles(ctx, []string{"-"}, func(m Match) error {
+			cancel() // While the worker is blocked reading the next line
+			return nil
+		})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("SearchFiles blocked after cancel")
+	}
+}
@@ -0,0 +1,314 @@
+package gzindex
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goiface/3_io/faultio"
+)
+
+// genText returns n lines of text with repetitions (back references).
+func genText(n int) []byte {
+	words := []string{"road", "wood", "yellow", "traveler", "the", "and", "diverged", "undergrowth"}
+	rnd := rand.New(rand.NewSource(3))
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "%06d:", i+1)
+		for j := rnd.Intn(12); j >= 0; j-- {
+			fmt.Fprintf(&buf, " %s", words[rnd.Intn(len(words))])
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// gzipData compresses data in members gzip members.
+func gzipData(t testing.TB, data []byte, level, members int) []byte {
+	var buf bytes.Buffer
+	size := len(data)/members + 1
+	for len(data) > 0 {
+		chunk := data[:min(size, len(data))]
+		data = data[len(chunk):]
+
+		w, err := gzip.NewWriterLevel(&buf, level)
+		require.NoError(t, err)
+		w.Name, w.Comment, w.Extra = "roads.txt", "frost", []byte{1, 2, 3}
+		_, err = w.Write(chunk)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+	return buf.Bytes()
+}
+
+func TestIndex(t *testing.T) {
+	data := genText(20_000)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+
+	testCases := []struct {
+		name    string
+		level   int
+		members int
+	}{
+		{"default", gzip.DefaultCompression, 1},
+		{"best", gzip.BestCompression, 1},
+		{"speed", gzip.BestSpeed, 1},
+		{"huffman", gzip.HuffmanOnly, 1},
+		{"stored", gzip.NoCompression, 1},
+		{"multistream", gzip.DefaultCompression, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gz := gzipData(t, data, tc.level, tc.members)
+			idx, err := Build(bytes.NewReader(gz), WithSpan(16<<10))
+			require.NoError(t, err)
+			require.Equal(t, int64(len(data)), idx.Size)
+			require.Equal(t, int64(len(lines)), idx.Lines)
+			require.Equal(t, int64(len(gz)), idx.CompressedSize)
+			require.Greater(t, len(idx.Points), 3)
+
+			r := NewReader(bytes.NewReader(gz), idx)
+			out, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, data, out)
+
+			rnd := rand.New(rand.NewSource(1))
+			for i := 0; i < 50; i++ {
+				off := rnd.Int63n(int64(len(data)))
+				buf := make([]byte, rnd.Intn(100_000))
+				n, err := r.ReadAt(buf, off)
+				want := data[off:min(off+int64(len(buf)), int64(len(data)))]
+				if n < len(buf) {
+					require.Equal(t, io.EOF, err)
+				} else {
+					require.NoError(t, err)
+				}
+				require.Equal(t, want, buf[:n], "offset %d", off)
+			}
+
+			for _, n := range []int64{1, 2, 1000, 10_000, 12_345, int64(len(lines))} {
+				line, err := r.Line(n)
+				require.NoError(t, err)
+				require.Equal(t, lines[n-1], string(line), "line %d", n)
+			}
+
+			// At access points
+			for _, p := range idx.Points[1:] {
+				n := p.Lines + 1
+				if n > idx.Lines {
+					continue // Empty final block
+				}
+				line, err := r.Line(n)
+				require.NoError(t, err)
+				require.Equal(t, lines[n-1], string(line), "line %d", n)
+			}
+		})
+	}
+}
+
+func TestReader_Seek(t *testing.T) {
+	data := genText(10_000)
+	gz := gzipData(t, data, gzip.DefaultCompression, 1)
+	idx, err := Build(bytes.NewReader(gz), WithSpan(16<<10))
+	require.NoError(t, err)
+
+	r := NewReader(bytes.NewReader(gz), idx)
+	for _, off := range []int64{100_000, 100_100, 10, 200_000, int64(len(data)) - 5} {
+		pos, err := r.Seek(off, io.SeekStart)
+		require.NoError(t, err)
+		require.Equal(t, off, pos)
+
+		buf := make([]byte, 5)
+		_, err = io.ReadFull(r, buf)
+		require.NoError(t, err)
+		require.Equal(t, data[off:off+5], buf)
+	}
+
+	_, err = r.Read(make([]byte, 1))
+	require.Equal(t, io.EOF, err)
+
+	pos, err := r.Seek(-10, io.SeekEnd)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)-10), pos)
+
+	_, err = r.Seek(-1, io.SeekStart)
+	require.Error(t, err)
+}
+
+func TestLine_NoFinalNewline(t *testing.T) {
+	gz := gzipData(t, []byte("one\ntwo\n\nfour"), gzip.DefaultCompression, 1)
+	idx, err := Build(bytes.NewReader(gz))
+	require.NoError(t, err)
+	require.Equal(t, int64(4), idx.Lines)
+
+	r := NewReader(bytes.NewReader(gz), idx)
+	for n, want := range []string{"one", "two", "", "four"} {
+		line, err := r.Line(int64(n + 1))
+		require.NoError(t, err)
+		require.Equal(t, want, string(line))
+	}
+
+	_, err = r.Line(5)
+	require.Equal(t, io.EOF, err)
+	_, err = r.Line(0)
+	require.Error(t, err)
+}
+
+func TestBuild_Errors(t *testing.T) {
+	data := genText(1000)
+	gz := gzipData(t, data, gzip.DefaultCompression, 1)
+
+	t.Run("truncated", func(t *testing.T) {
+		r := faultio.NewReader(bytes.NewReader(gz), faultio.TruncateAt(int64(len(gz)/2), nil))
+		_, err := Build(r)
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+
+	t.Run("checksum", func(t *testing.T) {
+		r := faultio.NewReader(bytes.NewReader(gz), faultio.CorruptAt(int64(len(gz)-6)))
+		_, err := Build(r)
+		require.ErrorIs(t, err, gzip.ErrChecksum)
+	})
+
+	t.Run("header", func(t *testing.T) {
+		r := faultio.NewReader(bytes.NewReader(gz), faultio.CorruptAt(1))
+		_, err := Build(r)
+		require.ErrorIs(t, err, gzip.ErrHeader)
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		// Corrupt data either fails to decode or fails the checksum
+		for off := int64(40); off < int64(len(gz)-8); off += 97 {
+			r := faultio.NewReader(bytes.NewReader(gz), faultio.CorruptAt(off))
+			_, err := Build(r)
+			var cerr flate.CorruptInputError
+			if !errors.As(err, &cerr) {
+				require.ErrorIs(t, err, gzip.ErrChecksum, "offset %d", off)
+			}
+		}
+	})
+
+	t.Run("span", func(t *testing.T) {
+		_, err := Build(bytes.NewReader(gz), WithSpan(0))
+		require.Error(t, err)
+	})
+}
+
+func TestIndex_WriteTo(t *testing.T) {
+	data := genText(10_000)
+	gz := gzipData(t, data, gzip.DefaultCompression, 1)
+	idx, err := Build(bytes.NewReader(gz), WithSpan(64<<10))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	n, err := idx.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(buf.Len()), n)
+	require.Less(t, buf.Len(), len(idx.Points)*windowSize/2, "compressed windows")
+
+	idx2, err := ReadIndex(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, idx, idx2)
+
+	_, err = ReadIndex(strings.NewReader("GZIP\x01"))
+	require.ErrorIs(t, err, ErrFormat)
+
+	_, err = ReadIndex(bytes.NewReader(buf.Bytes()[:buf.Len()/2]))
+	require.ErrorIs(t, err, ErrFormat)
+}
+
+func TestOpen(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "roads.txt.gz")
+	data := genText(10_000)
+	err := os.WriteFile(fileName, gzipData(t, data, gzip.DefaultCompression, 1), 0666)
+	require.NoError(t, err)
+
+	r, err := Open(fileName, WithSpan(64<<10))
+	require.NoError(t, err)
+	line, err := r.Line(5000)
+	require.NoError(t, err)
+	require.Equal(t, "005000:", string(line[:7]))
+	require.NoError(t, r.Close())
+
+	// Sidecar
+	file, err := os.Open(SidecarName(fileName))
+	require.NoError(t, err)
+	idx, err := ReadIndex(file)
+	file.Close()
+	require.NoError(t, err)
+	require.Equal(t, r.Index(), idx)
+
+	r, err = Open(fileName)
+	require.NoError(t, err)
+	require.Equal(t, idx, r.Index())
+	r.Close()
+
+	// Stale sidecar
+	data = genText(100)
+	err = os.WriteFile(fileName, gzipData(t, data, gzip.DefaultCompression, 1), 0666)
+	require.NoError(t, err)
+
+	r, err = Open(fileName)
+	require.NoError(t, err)
+	defer r.Close()
+	require.Equal(t, int64(100), r.Index().Lines)
+}
+
+func TestOpen_Roads(t *testing.T) {
+	// Copy, Open writes the sidecar next to the file
+	gz, err := os.ReadFile(filepath.Join("..", "challenge", "roads.txt.gz"))
+	require.NoError(t, err)
+	fileName := filepath.Join(t.TempDir(), "roads.txt.gz")
+	err = os.WriteFile(fileName, gz, 0666)
+	require.NoError(t, err)
+
+	r, err := Open(fileName)
+	require.NoError(t, err)
+	defer r.Close()
+
+	line, err := r.Line(1)
+	require.NoError(t, err)
+	require.Equal(t, "Two roads diverged in a yellow wood,", string(line))
+	require.Equal(t, int64(25), r.Index().Lines)
+}
+
+func BenchmarkLine(b *testing.B) {
+	data := genText(200_000)
+	gz := gzipData(b, data, gzip.DefaultCompression, 1)
+	idx, err := Build(bytes.NewReader(gz))
+	require.NoError(b, err)
+	r := NewReader(bytes.NewReader(gz), idx)
+
+	b.Run("Index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := r.Line(190_000); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("gzip", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			gr, err := gzip.NewReader(bytes.NewReader(gz))
+			if err != nil {
+				b.Fatal(err)
+			}
+			br := bufio.NewReader(gr)
+			for n := 1; n < 190_000; n++ {
+				br.ReadSlice('\n')
+			}
+		}
+	})
+}
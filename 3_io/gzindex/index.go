@@ -0,0 +1,316 @@
+// Package gzindex indexes gzip files for random access, like zran.c in zlib.
+//
+// Build decompresses a file once, and records an access point every span
+// bytes of output: the position of a deflate block in the compressed data,
+// the 32KiB of output before it (the decompressor window) and the number of
+// lines before it. A Reader starts decompressing from the closest access
+// point to read a byte offset or a line.
+//
+// Open persists the index in a sidecar file next to the gzip file.
+package gzindex
+
+import (
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultSpan is the default distance between access points.
+const DefaultSpan = 1 << 20
+
+// ErrFormat is returned when reading an invalid index.
+var ErrFormat = errors.New("gzindex: invalid index format")
+
+// Point is an access point, the start of a deflate block.
+type Point struct {
+	In     int64  // Offset in bits in the compressed data
+	Out    int64  // Offset in the uncompressed data
+	Lines  int64  // Newlines before Out
+	Window []byte // Up to 32KiB of uncompressed data before Out
+}
+
+// Index is a gzip file index.
+type Index struct {
+	CompressedSize int64
+	ModTime        time.Time // Of the gzip file, set by Open
+	Span           int64
+	Size           int64 // Uncompressed size
+	Lines          int64 // Number of lines, including a last one without newline
+	Points         []Point
+}
+
+// Option configures Build.
+type Option func(*options)
+
+type options struct {
+	span int64
+}
+
+// WithSpan sets the distance between access points in uncompressed bytes,
+// the default is DefaultSpan. Every point costs up to 32KiB (before
+// compression) in the index.
+func WithSpan(n int64) Option {
+	return func(o *options) {
+		o.span = n
+	}
+}
+
+// Build decompresses the gzip data in r, verifying its checksums, and returns
+// its index.
+func Build(r io.Reader, opts ...Option) (*Index, error) {
+	o := options{span: DefaultSpan}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.span <= 0 {
+		return nil, fmt.Errorf("gzindex: bad span - %d", o.span)
+	}
+
+	idx := Index{
+		Span:   o.span,
+		Points: []Point{{}}, // Start of the file
+	}
+
+	f := newInflater(r)
+	f.onBlock = func() {
+		last := idx.Points[len(idx.Points)-1]
+		if f.total-last.Out < o.span {
+			return
+		}
+
+		p := Point{
+			In:     f.br.offset(),
+			Out:    f.total,
+			Lines:  f.lines,
+			Window: f.window(),
+		}
+		idx.Points = append(idx.Points, p)
+	}
+
+	if _, err := io.Copy(io.Discard, f); err != nil {
+		return nil, err
+	}
+
+	idx.CompressedSize = f.br.off
+	idx.Size = f.total
+	idx.Lines = f.lines
+	if len(f.out) > 0 && f.out[len(f.out)-1] != '\n' {
+		idx.Lines++
+	}
+	return &idx, nil
+}
+
+// point returns the last access point before or at off.
+func (idx *Index) point(off int64) Point {
+	i := len(idx.Points) - 1
+	for i > 0 && idx.Points[i].Out > off {
+		i--
+	}
+	return idx.Points[i]
+}
+
+const (
+	indexMagic   = "GZIX"
+	indexVersion = 1
+)
+
+// WriteTo writes the index to w in a compact binary format, it implements
+// io.WriterTo.
+func (idx *Index) WriteTo(w io.Writer) (int64, error) {
+	cw := countWriter{w: w}
+	if _, err := io.WriteString(&cw, indexMagic+string(rune(indexVersion))); err != nil {
+		return cw.n, err
+	}
+
+	// Windows compress well
+	zw, err := flate.NewWriter(&cw, flate.BestSpeed)
+	if err != nil {
+		return cw.n, err
+	}
+	bw := bufio.NewWriter(zw)
+
+	var buf [binary.MaxVarintLen64]byte
+	putInt := func(v int64) {
+		n := binary.PutVarint(buf[:], v)
+		bw.Write(buf[:n])
+	}
+
+	putInt(idx.CompressedSize)
+	var mtime int64
+	if !idx.ModTime.IsZero() {
+		mtime = idx.ModTime.UnixNano()
+	}
+	putInt(mtime)
+	putInt(idx.Span)
+	putInt(idx.Size)
+	putInt(idx.Lines)
+	putInt(int64(len(idx.Points)))
+	for _, p := range idx.Points {
+		putInt(p.In)
+		putInt(p.Out)
+		putInt(p.Lines)
+		putInt(int64(len(p.Window)))
+		bw.Write(p.Window)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return cw.n, err
+	}
+	err = zw.Close()
+	return cw.n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(data []byte) (int, error) {
+	n, err := c.w.Write(data)
+	c.n += int64(n)
+	return n, err
+}
+
+// ReadIndex reads an index written by WriteTo.
+func ReadIndex(r io.Reader) (*Index, error) {
+	hdr := make([]byte, len(indexMagic)+1)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, fmt.Errorf("%w - %s", ErrFormat, err)
+	}
+	if string(hdr[:len(indexMagic)]) != indexMagic || hdr[len(indexMagic)] != indexVersion {
+		return nil, ErrFormat
+	}
+
+	br := bufio.NewReader(flate.NewReader(r))
+	var err error
+	getInt := func(min, max int64) int64 {
+		if err != nil {
+			return 0
+		}
+		var v int64
+		v, err = binary.ReadVarint(br)
+		if err == nil && (v < min || v > max) {
+			err = fmt.Errorf("%d out of range", v)
+		}
+		return v
+	}
+
+	const maxInt = 1<<63 - 1
+	var idx Index
+	idx.CompressedSize = getInt(0, maxInt)
+	if mtime := getInt(-maxInt, maxInt); mtime != 0 {
+		idx.ModTime = time.Unix(0, mtime)
+	}
+	idx.Span = getInt(1, maxInt)
+	idx.Size = getInt(0, maxInt)
+	idx.Lines = getInt(0, maxInt)
+	maxPoints := int64(1)
+	if idx.Span > 0 {
+		maxPoints += idx.Size / idx.Span // Points are at least Span apart
+	}
+	n := getInt(1, maxPoints)
+	for i := int64(0); i < n && err == nil; i++ {
+		var p Point
+		p.In = getInt(0, idx.CompressedSize*8)
+		p.Out = getInt(0, idx.Size)
+		p.Lines = getInt(0, idx.Lines)
+		if size := getInt(0, windowSize); size > 0 {
+			p.Window = make([]byte, size)
+			_, err = io.ReadFull(br, p.Window)
+		}
+		idx.Points = append(idx.Points, p)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w - %s", ErrFormat, err)
+	}
+	if p := idx.Points[0]; p.In != 0 || p.Out != 0 {
+		return nil, fmt.Errorf("%w - bad first point", ErrFormat)
+	}
+	return &idx, nil
+}
+
+// SidecarName returns the index file name for the gzip file fileName.
+func SidecarName(fileName string) string {
+	return fileName + ".idx"
+}
+
+// Open opens the gzip file fileName for random access. It uses the index in
+// the sidecar file if it is up to date, otherwise it builds the index and
+// tries to save it.
+func Open(fileName string, opts ...Option) (*Reader, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := openIndex(file, opts)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%q: %w", fileName, err)
+	}
+
+	r := NewReader(file, idx)
+	r.closer = file
+	return r, nil
+}
+
+func openIndex(file *os.File, opts []Option) (*Index, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	sidecar := SidecarName(file.Name())
+	if idx, err := loadIndex(sidecar); err == nil {
+		if idx.CompressedSize == info.Size() && idx.ModTime.Equal(info.ModTime()) {
+			return idx, nil
+		}
+	}
+
+	idx, err := Build(io.NewSectionReader(file, 0, info.Size()), opts...)
+	if err != nil {
+		return nil, err
+	}
+	idx.ModTime = info.ModTime()
+
+	// The index is a cache, the gzip file might be in a read only directory
+	saveIndex(sidecar, idx)
+	return idx, nil
+}
+
+func loadIndex(fileName string) (*Index, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadIndex(file)
+}
+
+// saveIndex writes idx to fileName, using a temporary file so readers never
+// see a partial index.
+func saveIndex(fileName string, idx *Index) error {
+	tmp, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after Rename
+
+	if _, err := idx.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), fileName)
+}
@@ -0,0 +1,624 @@
+package gzindex
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"hash/crc32"
+	"io"
+	"sync"
+)
+
+// The standard library compress/flate can't tell where deflate blocks start,
+// or resume decompression in the middle of a stream. This file is a small
+// inflater (see RFC 1951 & RFC 1952) that can.
+
+const (
+	windowSize = 1 << 15 // Maximal back reference distance
+	maxCodeLen = 15
+)
+
+// bitReader reads bits, least significant first, and tracks its offset.
+type bitReader struct {
+	r    io.ByteReader
+	bits uint64
+	n    uint  // Number of bits in bits
+	off  int64 // Bytes read from r
+}
+
+// offset returns the offset in bits of the next unread bit.
+func (b *bitReader) offset() int64 {
+	return b.off*8 - int64(b.n)
+}
+
+// fill reads bytes until there are n bits, it stops silently at EOF.
+func (b *bitReader) fill(n uint) error {
+	for b.n < n {
+		c, err := b.r.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		b.bits |= uint64(c) << b.n
+		b.n += 8
+		b.off++
+	}
+	return nil
+}
+
+func (b *bitReader) read(n uint) (uint32, error) {
+	if err := b.fill(n); err != nil {
+		return 0, err
+	}
+	if b.n < n {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	v := uint32(b.bits & (1<<n - 1))
+	b.bits >>= n
+	b.n -= n
+	return v, nil
+}
+
+// align drops the bits up to the next byte boundary.
+func (b *bitReader) align() {
+	b.bits >>= b.n % 8
+	b.n -= b.n % 8
+}
+
+// huffman is a canonical Huffman code, decoded with a single lookup table.
+type huffman struct {
+	table  []uint32 // Next maxLen bits -> symbol<<4 | length, 0 is invalid
+	maxLen uint
+}
+
+// init builds the code from the code lengths of every symbol. Incomplete
+// codes are allowed, RFC 1951 permits them for distances.
+func (h *huffman) init(lengths []uint8) bool {
+	var count [maxCodeLen + 1]int
+	var maxLen uint
+	for _, l := range lengths {
+		count[l]++
+		maxLen = max(maxLen, uint(l))
+	}
+	count[0] = 0
+
+	left := 1
+	for l := 1; l <= maxCodeLen; l++ {
+		left = left<<1 - count[l]
+		if left < 0 {
+			return false // Over subscribed
+		}
+	}
+
+	var next [maxCodeLen + 1]int
+	code := 0
+	for l := 1; l <= maxCodeLen; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+	}
+
+	h.maxLen = maxLen
+	size := 1 << maxLen
+	if cap(h.table) >= size {
+		h.table = h.table[:size]
+		clear(h.table)
+	} else {
+		h.table = make([]uint32, size)
+	}
+
+	for sym, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		code := next[l]
+		next[l]++
+
+		// Codes are packed most significant bit first
+		rev := 0
+		for i := uint8(0); i < l; i++ {
+			rev = rev<<1 | (code>>i)&1
+		}
+		for i := rev; i < size; i += 1 << l {
+			h.table[i] = uint32(sym)<<4 | uint32(l)
+		}
+	}
+	return true
+}
+
+func (h *huffman) decode(b *bitReader) (int, error) {
+	if err := b.fill(h.maxLen); err != nil {
+		return 0, err
+	}
+
+	e := h.table[b.bits&(1<<h.maxLen-1)]
+	n := uint(e & 15)
+	switch {
+	case n == 0:
+		return 0, errCorrupt
+	case n > b.n:
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	b.bits >>= n
+	b.n -= n
+	return int(e >> 4), nil
+}
+
+// errCorrupt is converted to flate.CorruptInputError with the offset.
+var errCorrupt = errors.New("corrupt")
+
+var (
+	lengthBase  = [...]int{3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31, 35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 258}
+	lengthExtra = [...]uint{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0}
+	distBase    = [...]int{1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193, 257, 385, 513, 769, 1025, 1537, 2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577}
+	distExtra   = [...]uint{0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13}
+
+	codeLenOrder = [...]int{16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15}
+)
+
+// The fixed codes of block type 1
+var (
+	fixedLit = sync.OnceValue(func() *huffman {
+		var lengths [288]uint8
+		for i := range lengths {
+			switch {
+			case i < 144:
+				lengths[i] = 8
+			case i < 256:
+				lengths[i] = 9
+			case i < 280:
+				lengths[i] = 7
+			default:
+				lengths[i] = 8
+			}
+		}
+		return fixedHuffman(lengths[:])
+	})
+
+	fixedDist = sync.OnceValue(func() *huffman {
+		var lengths [30]uint8
+		for i := range lengths {
+			lengths[i] = 5
+		}
+		return fixedHuffman(lengths[:])
+	})
+)
+
+func fixedHuffman(lengths []uint8) *huffman {
+	var h huffman
+	h.init(lengths)
+	return &h
+}
+
+type state int
+
+const (
+	stHeader  state = iota // gzip member header
+	stBlock                // deflate block header
+	stStored               // in a stored block
+	stHuffman              // in a compressed block
+	stTrailer              // gzip member trailer
+	stEOF
+)
+
+// inflater decompresses a gzip stream, possibly starting at a deflate block
+// in the middle of it.
+type inflater struct {
+	br     bitReader
+	state  state
+	final  bool // Current block is the last of the member
+	stored int  // Bytes left in a stored block
+
+	lit, dist       *huffman
+	dynLit, dynDist huffman
+
+	// out holds at least windowSize bytes of history before rpos
+	out  []byte
+	rpos int
+
+	total int64 // Bytes of output
+	lines int64 // Newlines in output
+
+	// The checksum is verified only when starting at a member start
+	verify bool
+	crc    uint32
+	size   uint32
+
+	// onBlock is called before every deflate block header
+	onBlock func()
+
+	err error
+}
+
+// newInflater returns an inflater reading r from the start of a gzip stream.
+func newInflater(r io.Reader) *inflater {
+	f := inflater{
+		state:  stHeader,
+		verify: true,
+	}
+	f.br.r = byteReader(r)
+	return &f
+}
+
+// resume returns an inflater reading r from a deflate block header, skipping
+// the first skip bits of r, with window as history.
+func resume(r io.Reader, skip uint, window []byte, out, lines int64) (*inflater, error) {
+	f := inflater{
+		state: stBlock,
+		out:   append(make([]byte, 0, 4*windowSize), window...),
+		rpos:  len(window),
+		total: out,
+		lines: lines,
+	}
+	f.br.r = byteReader(r)
+	if _, err := f.br.read(skip); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func byteReader(r io.Reader) io.ByteReader {
+	if br, ok := r.(io.ByteReader); ok {
+		return br
+	}
+	return bufio.NewReaderSize(r, 64<<10)
+}
+
+// Read implements io.Reader
+func (f *inflater) Read(p []byte) (int, error) {
+	for f.rpos == len(f.out) {
+		if f.err != nil {
+			return 0, f.err
+		}
+		f.err = f.step()
+	}
+
+	n := copy(p, f.out[f.rpos:])
+	f.rpos += n
+	return n, nil
+}
+
+// step decompresses some data, it returns io.EOF at the end of the stream.
+func (f *inflater) step() error {
+	// Keep windowSize bytes of history
+	if f.rpos > 2*windowSize {
+		n := copy(f.out, f.out[f.rpos-windowSize:])
+		f.out = f.out[:n]
+		f.rpos = windowSize
+	}
+
+	start := len(f.out)
+	err := f.decode()
+	f.account(f.out[start:])
+
+	switch {
+	case err == errCorrupt:
+		return flate.CorruptInputError(f.br.offset() / 8)
+	case err == io.EOF && f.state != stEOF:
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (f *inflater) account(data []byte) {
+	f.total += int64(len(data))
+	f.lines += int64(bytes.Count(data, []byte{'\n'}))
+	if f.verify {
+		f.crc = crc32.Update(f.crc, crc32.IEEETable, data)
+		f.size += uint32(len(data))
+	}
+}
+
+// decode decodes up to the end of a block, or until there are windowSize
+// new bytes.
+func (f *inflater) decode() error {
+	switch f.state {
+	case stHeader:
+		return f.header()
+	case stBlock:
+		if f.onBlock != nil {
+			f.onBlock()
+		}
+		return f.block()
+	case stStored:
+		return f.storedData()
+	case stHuffman:
+		return f.huffmanData()
+	case stTrailer:
+		return f.trailer()
+	}
+	return io.EOF
+}
+
+func (f *inflater) readByte() (byte, error) {
+	v, err := f.br.read(8)
+	return byte(v), err
+}
+
+func (f *inflater) header() error {
+	var hdr [10]byte
+	for i := range hdr {
+		c, err := f.readByte()
+		if err != nil {
+			return err
+		}
+		hdr[i] = c
+	}
+	if hdr[0] != 0x1f || hdr[1] != 0x8b || hdr[2] != 8 {
+		return gzip.ErrHeader
+	}
+
+	const (
+		flagHCRC    = 1 << 1
+		flagExtra   = 1 << 2
+		flagName    = 1 << 3
+		flagComment = 1 << 4
+	)
+	flags := hdr[3]
+
+	if flags&flagExtra != 0 {
+		lo, err := f.readByte()
+		if err != nil {
+			return err
+		}
+		hi, err := f.readByte()
+		if err != nil {
+			return err
+		}
+		for n := int(lo) | int(hi)<<8; n > 0; n-- {
+			if _, err := f.readByte(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, flag := range []byte{flagName, flagComment} {
+		if flags&flag == 0 {
+			continue
+		}
+		for {
+			c, err := f.readByte()
+			if err != nil {
+				return err
+			}
+			if c == 0 {
+				break
+			}
+		}
+	}
+
+	if flags&flagHCRC != 0 {
+		if _, err := f.br.read(16); err != nil {
+			return err
+		}
+	}
+
+	f.crc, f.size = 0, 0
+	f.state = stBlock
+	return nil
+}
+
+func (f *inflater) trailer() error {
+	f.br.align()
+	crc, err := f.br.read(32)
+	if err != nil {
+		return err
+	}
+	size, err := f.br.read(32)
+	if err != nil {
+		return err
+	}
+	if f.verify && (crc != f.crc || size != f.size) {
+		return gzip.ErrChecksum
+	}
+	// Next member starts at a member boundary, it can be verified
+	f.verify = true
+
+	// Multistream, like gzip.Reader
+	if err := f.br.fill(8); err != nil {
+		return err
+	}
+	if f.br.n == 0 {
+		f.state = stEOF
+		return io.EOF
+	}
+
+	f.state = stHeader
+	return nil
+}
+
+func (f *inflater) block() error {
+	hdr, err := f.br.read(3)
+	if err != nil {
+		return err
+	}
+	f.final = hdr&1 == 1
+
+	switch hdr >> 1 {
+	case 0:
+		f.br.align()
+		v, err := f.br.read(32)
+		if err != nil {
+			return err
+		}
+		n, nn := v&0xffff, v>>16
+		if n != ^nn&0xffff {
+			return errCorrupt
+		}
+		f.stored = int(n)
+		f.state = stStored
+	case 1:
+		f.lit, f.dist = fixedLit(), fixedDist()
+		f.state = stHuffman
+	case 2:
+		if err := f.dynamic(); err != nil {
+			return err
+		}
+		f.lit, f.dist = &f.dynLit, &f.dynDist
+		f.state = stHuffman
+	default:
+		return errCorrupt
+	}
+
+	return nil
+}
+
+func (f *inflater) endBlock() {
+	if f.final {
+		f.state = stTrailer
+	} else {
+		f.state = stBlock
+	}
+}
+
+func (f *inflater) dynamic() error {
+	v, err := f.br.read(14)
+	if err != nil {
+		return err
+	}
+	nlit, ndist, nclen := int(v&0x1f)+257, int(v>>5&0x1f)+1, int(v>>10)+4
+	if nlit > 286 || ndist > 30 {
+		return errCorrupt
+	}
+
+	var clens [19]uint8
+	for i := 0; i < nclen; i++ {
+		l, err := f.br.read(3)
+		if err != nil {
+			return err
+		}
+		clens[codeLenOrder[i]] = uint8(l)
+	}
+	var cl huffman
+	if !cl.init(clens[:]) {
+		return errCorrupt
+	}
+
+	var lengths [286 + 30]uint8
+	for i := 0; i < nlit+ndist; {
+		sym, err := cl.decode(&f.br)
+		if err != nil {
+			return err
+		}
+		if sym < 16 {
+			lengths[i] = uint8(sym)
+			i++
+			continue
+		}
+
+		var rep uint32
+		var prev uint8
+		switch sym {
+		case 16:
+			if i == 0 {
+				return errCorrupt
+			}
+			prev = lengths[i-1]
+			rep, err = f.br.read(2)
+			rep += 3
+		case 17:
+			rep, err = f.br.read(3)
+			rep += 3
+		case 18:
+			rep, err = f.br.read(7)
+			rep += 11
+		}
+		if err != nil {
+			return err
+		}
+		if i+int(rep) > nlit+ndist {
+			return errCorrupt
+		}
+		for ; rep > 0; rep-- {
+			lengths[i] = prev
+			i++
+		}
+	}
+
+	if lengths[256] == 0 {
+		return errCorrupt // No end of block
+	}
+	if !f.dynLit.init(lengths[:nlit]) || !f.dynDist.init(lengths[nlit:nlit+ndist]) {
+		return errCorrupt
+	}
+	return nil
+}
+
+func (f *inflater) storedData() error {
+	n := min(f.stored, windowSize)
+	for i := 0; i < n; i++ {
+		c, err := f.readByte()
+		if err != nil {
+			return err
+		}
+		f.out = append(f.out, c)
+	}
+
+	f.stored -= n
+	if f.stored == 0 {
+		f.endBlock()
+	}
+	return nil
+}
+
+func (f *inflater) huffmanData() error {
+	for end := len(f.out) + windowSize; len(f.out) < end; {
+		sym, err := f.lit.decode(&f.br)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case sym < 256:
+			f.out = append(f.out, byte(sym))
+			continue
+		case sym == 256:
+			f.endBlock()
+			return nil
+		case sym > 285:
+			return errCorrupt
+		}
+
+		sym -= 257
+		extra, err := f.br.read(lengthExtra[sym])
+		if err != nil {
+			return err
+		}
+		length := lengthBase[sym] + int(extra)
+
+		dsym, err := f.dist.decode(&f.br)
+		if err != nil {
+			return err
+		}
+		if dsym >= len(distBase) {
+			return errCorrupt
+		}
+		extra, err = f.br.read(distExtra[dsym])
+		if err != nil {
+			return err
+		}
+		dist := distBase[dsym] + int(extra)
+		if dist > len(f.out) {
+			return errCorrupt
+		}
+
+		// Overlapping copies repeat the data
+		for i := len(f.out) - dist; length > 0; length-- {
+			f.out = append(f.out, f.out[i])
+			i++
+		}
+	}
+
+	return nil
+}
+
+// window returns the last windowSize bytes of output.
+func (f *inflater) window() []byte {
+	start := max(0, len(f.out)-windowSize)
+	return append([]byte(nil), f.out[start:]...)
+}
@@ -0,0 +1,189 @@
+package gzindex
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Reader reads the uncompressed data of an indexed gzip file at any offset.
+// Read and Seek are not safe for concurrent use, ReadAt and Line are.
+type Reader struct {
+	ra     io.ReaderAt
+	idx    *Index
+	closer io.Closer
+
+	off  int64     // Read offset
+	f    *inflater // Current Read stream
+	fpos int64     // f offset
+}
+
+// NewReader returns a reader of the gzip data in ra, indexed by idx.
+func NewReader(ra io.ReaderAt, idx *Index) *Reader {
+	return &Reader{ra: ra, idx: idx}
+}
+
+// Index returns the reader index.
+func (r *Reader) Index() *Index {
+	return r.idx
+}
+
+// stream returns an inflater at off, starting at the closest access point.
+func (r *Reader) stream(off int64) (*inflater, error) {
+	p := r.idx.point(off)
+	f, err := r.streamAt(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.CopyN(io.Discard, f, off-p.Out); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (r *Reader) streamAt(p Point) (*inflater, error) {
+	data := io.NewSectionReader(r.ra, p.In/8, r.idx.CompressedSize-p.In/8)
+	if p.In == 0 {
+		return newInflater(data), nil
+	}
+
+	return resume(data, uint(p.In%8), p.Window, p.Out, p.Lines)
+}
+
+// Read implements io.Reader
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.off >= r.idx.Size {
+		return 0, io.EOF
+	}
+
+	// Small forward seeks read from the current stream
+	if r.f != nil && r.fpos < r.off && r.off-r.fpos <= r.idx.Span {
+		if _, err := io.CopyN(io.Discard, r.f, r.off-r.fpos); err != nil {
+			r.f = nil
+			return 0, err
+		}
+		r.fpos = r.off
+	}
+
+	if r.f == nil || r.fpos != r.off {
+		f, err := r.stream(r.off)
+		if err != nil {
+			return 0, err
+		}
+		r.f, r.fpos = f, r.off
+	}
+
+	n, err := r.f.Read(p)
+	r.off += int64(n)
+	r.fpos += int64(n)
+	if err != nil && err != io.EOF {
+		r.f = nil
+	}
+	return n, err
+}
+
+var (
+	errWhence = errors.New("gzindex: invalid whence")
+	errOffset = errors.New("gzindex: invalid offset")
+)
+
+// Seek implements io.Seeker
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.off
+	case io.SeekEnd:
+		offset += r.idx.Size
+	default:
+		return 0, errWhence
+	}
+
+	if offset < 0 {
+		return 0, errOffset
+	}
+
+	r.off = offset
+	return offset, nil
+}
+
+// ReadAt implements io.ReaderAt
+func (r *Reader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errOffset
+	}
+	if off >= r.idx.Size {
+		return 0, io.EOF
+	}
+
+	f, err := r.stream(off)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.ReadFull(f, p)
+	if err == io.ErrUnexpectedEOF && off+int64(n) == r.idx.Size {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Line returns line n, starting at 1, without the trailing newline. It
+// returns io.EOF if there are less than n lines.
+func (r *Reader) Line(n int64) ([]byte, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("gzindex: bad line number - %d", n)
+	}
+	if n > r.idx.Lines {
+		return nil, io.EOF
+	}
+
+	// Line n starts after the last point with at most n-2 newlines before it
+	i := len(r.idx.Points) - 1
+	for i > 0 && r.idx.Points[i].Lines > n-2 {
+		i--
+	}
+	p := r.idx.Points[i]
+
+	f, err := r.streamAt(p)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	for skip := n - 1 - p.Lines; skip > 0; skip-- {
+		if err := skipLine(br); err != nil {
+			return nil, err
+		}
+	}
+
+	line, err := br.ReadBytes('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(line, []byte{'\n'}), nil
+}
+
+// skipLine skips up to and including the next newline, without buffering
+// long lines.
+func skipLine(br *bufio.Reader) error {
+	for {
+		_, err := br.ReadSlice('\n')
+		if err != bufio.ErrBufferFull {
+			return err
+		}
+	}
+}
+
+// Close closes the gzip file if the reader was returned by Open.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
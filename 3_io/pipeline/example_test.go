@@ -0,0 +1,23 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func ExampleThen() {
+	words := []string{"Hope", "is", "the", "thing", "with", "feathers"}
+
+	upper := Map(func(ctx context.Context, s string) (string, error) {
+		return strings.ToUpper(s), nil
+	}, Workers(4), Ordered())
+	long := Filter(func(s string) bool { return len(s) > 2 })
+	p := Then(Then(upper, long), Batch[string](2), Buffer(8))
+
+	out, err := Run(context.Background(), p, words)
+	fmt.Println(out, err)
+
+	// Output:
+	// [[HOPE THE] [THING WITH] [FEATHERS]] <nil>
+}
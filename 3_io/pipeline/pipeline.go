@@ -0,0 +1,375 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Stage transforms a stream of In values to a stream of Out values.
+// Run reads from in until it's closed, sends results to out and must not close
+// out. Run should stop and return when ctx is done.
+type Stage[In, Out any] interface {
+	Run(ctx context.Context, in <-chan In, out chan<- Out) error
+}
+
+// StageFunc is a function implementing Stage.
+type StageFunc[In, Out any] func(ctx context.Context, in <-chan In, out chan<- Out) error
+
+// Run implements Stage
+func (f StageFunc[In, Out]) Run(ctx context.Context, in <-chan In, out chan<- Out) error {
+	return f(ctx, in, out)
+}
+
+// Option configures a stage.
+type Option func(*options)
+
+type options struct {
+	workers int
+	ordered bool
+	buffer  int
+}
+
+func newOptions(opts []Option) options {
+	o := options{workers: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Workers sets the number of goroutines running a Map stage.
+func Workers(n int) Option {
+	return func(o *options) {
+		o.workers = max(n, 1)
+	}
+}
+
+// Ordered makes a Map stage with several workers emit results in input order.
+func Ordered() Option {
+	return func(o *options) {
+		o.ordered = true
+	}
+}
+
+// Buffer sets the size of the channel between stages (default 0).
+func Buffer(n int) Option {
+	return func(o *options) {
+		o.buffer = n
+	}
+}
+
+// send sends v to out unless ctx is done first.
+func send[T any](ctx context.Context, out chan<- T, v T) error {
+	select {
+	case out <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// recv receives a value from in unless ctx is done first, ok is false once in
+// is closed or ctx is done.
+func recv[T any](ctx context.Context, in <-chan T) (v T, ok bool) {
+	select {
+	case v, ok = <-in:
+		return v, ok
+	case <-ctx.Done():
+		return v, false
+	}
+}
+
+// errDone cancels the upstream stage once the downstream one returned.
+var errDone = errors.New("downstream done")
+
+// Then connects the output of first to the input of second.
+// The first error in either stage cancels both, and is returned by Run.
+func Then[A, B, C any](first Stage[A, B], second Stage[B, C], opts ...Option) Stage[A, C] {
+	o := newOptions(opts)
+	return StageFunc[A, C](func(ctx context.Context, in <-chan A, out chan<- C) error {
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+
+		mid := make(chan B, o.buffer)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			err := first.Run(ctx, in, mid)
+			close(mid)
+			if err != nil {
+				cancel(err)
+			}
+		}()
+
+		if err := second.Run(ctx, mid, out); err != nil {
+			cancel(err)
+		}
+		// Stop first if second returned before reading everything
+		cancel(errDone)
+		<-done
+
+		if err := context.Cause(ctx); err != errDone {
+			return err
+		}
+		return nil
+	})
+}
+
+// Map returns a stage calling fn on every value.
+// With Workers, fn is called concurrently and results are emitted as they
+// are ready, unless Ordered is set.
+func Map[In, Out any](fn func(context.Context, In) (Out, error), opts ...Option) Stage[In, Out] {
+	o := newOptions(opts)
+
+	stage := StageFunc[In, Out](func(ctx context.Context, in <-chan In, out chan<- Out) error {
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return ctx.Err()
+			}
+			r, err := fn(ctx, v)
+			if err != nil {
+				return err
+			}
+			if err := send(ctx, out, r); err != nil {
+				return err
+			}
+		}
+	})
+
+	switch {
+	case o.workers == 1:
+		return stage
+	case o.ordered:
+		return orderedMap(fn, o.workers)
+	}
+
+	return FanOut[In, Out](o.workers, stage)
+}
+
+type result[T any] struct {
+	value T
+	err   error
+}
+
+type job[In, Out any] struct {
+	value In
+	res   chan result[Out]
+}
+
+// orderedMap runs fn in n workers, a queue of pending results keeps the input
+// order and bounds the number of values in flight.
+func orderedMap[In, Out any](fn func(context.Context, In) (Out, error), n int) Stage[In, Out] {
+	return StageFunc[In, Out](func(ctx context.Context, in <-chan In, out chan<- Out) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		jobs := make(chan job[In, Out])
+		pending := make(chan chan result[Out], n)
+
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range jobs {
+					v, err := fn(ctx, j.value)
+					j.res <- result[Out]{v, err}
+				}
+			}()
+		}
+
+		go func() {
+			defer close(pending)
+			defer close(jobs)
+			for {
+				v, ok := recv(ctx, in)
+				if !ok {
+					return
+				}
+				res := make(chan result[Out], 1)
+				if send(ctx, pending, res) != nil {
+					return
+				}
+				if send(ctx, jobs, job[In, Out]{v, res}) != nil {
+					return
+				}
+			}
+		}()
+
+		var err error
+		for res := range pending {
+			if err != nil {
+				continue // drain so the feeder exits
+			}
+
+			var r result[Out]
+			select {
+			case r = <-res:
+			case <-ctx.Done():
+				err = ctx.Err()
+				continue
+			}
+
+			if r.err != nil {
+				err = r.err
+				cancel()
+				continue
+			}
+			if err = send(ctx, out, r.value); err != nil {
+				cancel()
+			}
+		}
+		wg.Wait()
+
+		if err != nil {
+			return err
+		}
+		return ctx.Err()
+	})
+}
+
+// Filter returns a stage passing only values where keep returns true.
+func Filter[T any](keep func(T) bool) Stage[T, T] {
+	return StageFunc[T, T](func(ctx context.Context, in <-chan T, out chan<- T) error {
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return ctx.Err()
+			}
+			if !keep(v) {
+				continue
+			}
+			if err := send(ctx, out, v); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// Batch returns a stage grouping values in slices of size values, the last
+// slice may be shorter.
+func Batch[T any](size int) Stage[T, []T] {
+	return StageFunc[T, []T](func(ctx context.Context, in <-chan T, out chan<- []T) error {
+		batch := make([]T, 0, size)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				break
+			}
+			batch = append(batch, v)
+			if len(batch) < size {
+				continue
+			}
+			if err := send(ctx, out, batch); err != nil {
+				return err
+			}
+			batch = make([]T, 0, size)
+		}
+
+		if len(batch) > 0 {
+			if err := send(ctx, out, batch); err != nil {
+				return err
+			}
+		}
+		return ctx.Err()
+	})
+}
+
+// FanOut runs n copies of s reading from the same input and writing to the
+// same output. The output order is not kept.
+func FanOut[In, Out any](n int, s Stage[In, Out]) Stage[In, Out] {
+	return StageFunc[In, Out](func(ctx context.Context, in <-chan In, out chan<- Out) error {
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := s.Run(ctx, in, out); err != nil {
+					cancel(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		return context.Cause(ctx)
+	})
+}
+
+// Merge sends values from all chans to the returned channel (fan in), which is
+// closed once all chans are closed.
+func Merge[T any](ctx context.Context, chans ...<-chan T) <-chan T {
+	out := make(chan T)
+
+	var wg sync.WaitGroup
+	for _, ch := range chans {
+		wg.Add(1)
+		go func(ch <-chan T) {
+			defer wg.Done()
+			for v := range ch {
+				if send(ctx, out, v) != nil {
+					return
+				}
+			}
+		}(ch)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// Start runs s in a goroutine reading from in, wait returns the stage error
+// once it is done. The returned channel is closed when s is done.
+func Start[In, Out any](ctx context.Context, s Stage[In, Out], in <-chan In, opts ...Option) (out <-chan Out, wait func() error) {
+	o := newOptions(opts)
+	ch := make(chan Out, o.buffer)
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- s.Run(ctx, in, ch)
+		close(ch)
+	}()
+
+	var once sync.Once
+	var err error
+	wait = func() error {
+		once.Do(func() {
+			err = <-errc
+		})
+		return err
+	}
+
+	return ch, wait
+}
+
+// Run runs s on values and returns the results.
+func Run[In, Out any](ctx context.Context, s Stage[In, Out], values []In) ([]Out, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	in := make(chan In)
+	go func() {
+		defer close(in)
+		for _, v := range values {
+			if send(ctx, in, v) != nil {
+				return
+			}
+		}
+	}()
+
+	out, wait := Start(ctx, s, in)
+	var results []Out
+	for v := range out {
+		results = append(results, v)
+	}
+
+	return results, wait()
+}
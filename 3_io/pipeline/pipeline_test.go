@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func square(ctx context.Context, n int) (int, error) {
+	return n * n, nil
+}
+
+func numbers(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
+
+func TestMap_Ordered(t *testing.T) {
+	slow := func(ctx context.Context, n int) (int, error) {
+		// Later values finish first
+		time.Sleep(time.Duration(10-n%10) * 100 * time.Microsecond)
+		return n * n, nil
+	}
+
+	out, err := Run(context.Background(), Map(slow, Workers(8), Ordered()), numbers(100))
+	require.NoError(t, err)
+	for i, v := range out {
+		require.Equal(t, i*i, v)
+	}
+}
+
+func TestMap_Unordered(t *testing.T) {
+	out, err := Run(context.Background(), Map(square, Workers(8)), numbers(100))
+	require.NoError(t, err)
+	require.Len(t, out, 100)
+	sort.Ints(out)
+	for i, v := range out {
+		require.Equal(t, i*i, v)
+	}
+}
+
+func TestThen_Error(t *testing.T) {
+	var calls atomic.Int64
+	fail := func(ctx context.Context, n int) (int, error) {
+		calls.Add(1)
+		if n == 25 {
+			return 0, fmt.Errorf("bad value: %d", n)
+		}
+		return n, nil
+	}
+
+	for _, opts := range [][]Option{nil, {Workers(4)}, {Workers(4), Ordered()}} {
+		p := Then(Map(square, opts...), Map(fail, opts...))
+		_, err := Run(context.Background(), p, numbers(1000))
+		require.EqualError(t, err, "bad value: 25")
+	}
+}
+
+func TestThen_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	block := func(ctx context.Context, n int) (int, error) {
+		if n == 3 {
+			cancel()
+		}
+		return n, nil
+	}
+
+	p := Then(Map(block), Batch[int](2))
+	_, err := Run(ctx, p, numbers(1000))
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestThen_EarlyReturn(t *testing.T) {
+	first := StageFunc[int, int](func(ctx context.Context, in <-chan int, out chan<- int) error {
+		v := <-in
+		return send(ctx, out, v)
+	})
+
+	p := Then(Map(square), first)
+	out, err := Run(context.Background(), p, numbers(1000))
+	require.NoError(t, err)
+	require.Equal(t, []int{0}, out)
+}
+
+func TestMerge(t *testing.T) {
+	ctx := context.Background()
+	a, b := make(chan int), make(chan int)
+	go func() {
+		for i := 0; i < 3; i++ {
+			a <- i
+			b <- i + 10
+		}
+		close(a)
+		close(b)
+	}()
+
+	var out []int
+	for v := range Merge(ctx, a, b) {
+		out = append(out, v)
+	}
+	sort.Ints(out)
+	require.Equal(t, []int{0, 1, 2, 10, 11, 12}, out)
+}
+
+func TestStages_CancelIdle(t *testing.T) {
+	positive := Filter(func(n int) bool { return n > 0 })
+	stages := map[string]Stage[int, []int]{
+		"map":     Then(Map(square), Batch[int](1)),
+		"workers": Then(Map(square, Workers(4)), Batch[int](1)),
+		"ordered": Then(Map(square, Workers(4), Ordered()), Batch[int](1)),
+		"filter":  Then(Then(positive, Map(square)), Batch[int](1)),
+	}
+
+	for name, s := range stages {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			in := make(chan int) // Never closed
+			out, wait := Start(ctx, s, in)
+
+			in <- 3
+			require.Equal(t, []int{9}, <-out)
+
+			cancel()
+			done := make(chan error)
+			go func() { done <- wait() }()
+			select {
+			case err := <-done:
+				require.ErrorIs(t, err, context.Canceled)
+			case <-time.After(time.Second):
+				t.Fatal("stage did not return after cancel")
+			}
+		})
+	}
+}
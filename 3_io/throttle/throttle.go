@@ -0,0 +1,168 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"goiface/2_design/clock"
+)
+
+// Limiter is a token bucket limiting bytes per second.
+// A Limiter can be shared between several readers and writers to limit their
+// total bandwidth.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	burst  int
+	tokens float64 // negative when waiters reserved future tokens
+	last   time.Time
+	clock  clock.Clock
+}
+
+// Option configures a Limiter.
+type Option func(*Limiter)
+
+// WithClock sets the limiter clock, the default is the system clock.
+func WithClock(c clock.Clock) Option {
+	return func(l *Limiter) {
+		l.clock = c
+	}
+}
+
+// NewLimiter returns a limiter allowing rate bytes per second, with bursts of
+// up to burst bytes. The bucket starts full. It panics if rate or burst is
+// not positive, like time.NewTicker.
+func NewLimiter(rate, burst int, opts ...Option) *Limiter {
+	if rate <= 0 || burst <= 0 {
+		panic(fmt.Sprintf("throttle: non-positive rate (%d) or burst (%d)", rate, burst))
+	}
+
+	l := Limiter{
+		rate:   float64(rate),
+		burst:  burst,
+		tokens: float64(burst),
+	}
+	for _, opt := range opts {
+		opt(&l)
+	}
+	l.clock = clock.Or(l.clock)
+	l.last = l.clock.Now()
+
+	return &l
+}
+
+// Burst returns the limiter burst size.
+func (l *Limiter) Burst() int {
+	return l.burst
+}
+
+// WaitN blocks until n bytes are allowed or ctx is done. n larger than the
+// burst size is waited for in several steps.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	for n > 0 {
+		chunk := min(n, l.burst)
+		if err := l.wait(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+
+	return nil
+}
+
+func (l *Limiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := l.clock.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	l.tokens = min(l.tokens, float64(l.burst))
+	l.last = now
+
+	// Reserve the tokens, other waiters will queue after us
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	select {
+	case <-l.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		// Give back the reservation
+		l.mu.Lock()
+		l.tokens += float64(n)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Reader is a rate limited io.Reader.
+type Reader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *Limiter
+}
+
+// NewReader returns a reader limited by l, reads block until ctx is done.
+func NewReader(ctx context.Context, r io.Reader, l *Limiter) *Reader {
+	return &Reader{ctx: ctx, r: r, l: l}
+}
+
+// Read implements io.Reader
+func (r *Reader) Read(p []byte) (int, error) {
+	if len(p) > r.l.Burst() {
+		p = p[:r.l.Burst()]
+	}
+
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.l.WaitN(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+
+	return n, err
+}
+
+// Writer is a rate limited io.Writer.
+type Writer struct {
+	ctx context.Context
+	w   io.Writer
+	l   *Limiter
+}
+
+// NewWriter returns a writer limited by l, writes block until ctx is done.
+func NewWriter(ctx context.Context, w io.Writer, l *Limiter) *Writer {
+	return &Writer{ctx: ctx, w: w, l: l}
+}
+
+// Write implements io.Writer
+func (w *Writer) Write(data []byte) (int, error) {
+	total := 0
+	for len(data) > 0 {
+		chunk := data[:min(len(data), w.l.Burst())]
+		if err := w.l.WaitN(w.ctx, len(chunk)); err != nil {
+			return total, err
+		}
+
+		n, err := w.w.Write(chunk)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < len(chunk) {
+			return total, io.ErrShortWrite
+		}
+		data = data[n:]
+	}
+
+	return total, nil
+}
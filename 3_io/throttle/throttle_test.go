@@ -0,0 +1,92 @@
+package throttle
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goiface/2_design/clock"
+)
+
+func newFakeClock() *clock.Fake {
+	return clock.NewFake(time.Date(2024, 2, 11, 20, 27, 31, 0, time.UTC))
+}
+
+func TestWriter(t *testing.T) {
+	clock := newFakeClock()
+	l := NewLimiter(10, 10, WithClock(clock))
+	var buf bytes.Buffer
+	w := NewWriter(context.Background(), &buf, l)
+
+	done := make(chan error)
+	go func() {
+		_, err := w.Write(make([]byte, 25))
+		done <- err
+	}()
+
+	// burst is used right away, then 1s for every 10 bytes
+	require.Eventually(t, func() bool { return clock.Waiters() == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, 10, buf.Len())
+
+	clock.Advance(time.Second)
+	require.Eventually(t, func() bool { return clock.Waiters() == 1 && buf.Len() == 20 }, time.Second, time.Millisecond)
+
+	clock.Advance(500 * time.Millisecond)
+	require.NoError(t, <-done)
+	require.Equal(t, 25, buf.Len())
+}
+
+func TestReader_Shared(t *testing.T) {
+	clock := newFakeClock()
+	l := NewLimiter(100, 100, WithClock(clock))
+	ctx := context.Background()
+
+	r1 := NewReader(ctx, strings.NewReader(strings.Repeat("a", 100)), l)
+	data, err := io.ReadAll(r1)
+	require.NoError(t, err)
+	require.Len(t, data, 100)
+
+	// The bucket is empty, the second reader waits
+	r2 := NewReader(ctx, strings.NewReader(strings.Repeat("b", 50)), l)
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r2)
+		done <- data
+	}()
+
+	require.Eventually(t, func() bool { return clock.Waiters() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(500 * time.Millisecond)
+	require.Len(t, <-done, 50)
+}
+
+func TestLimiter_Cancel(t *testing.T) {
+	clock := newFakeClock()
+	l := NewLimiter(10, 10, WithClock(clock))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	require.NoError(t, l.WaitN(ctx, 10))
+
+	done := make(chan error)
+	go func() {
+		done <- l.WaitN(ctx, 10)
+	}()
+	require.Eventually(t, func() bool { return clock.Waiters() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+
+	// Canceled reservation is given back
+	clock.Advance(time.Second)
+	require.NoError(t, l.WaitN(context.Background(), 10))
+}
+
+func TestNewLimiter_Invalid(t *testing.T) {
+	for _, tc := range []struct{ rate, burst int }{{0, 10}, {-1, 10}, {10, 0}, {10, -1}} {
+		require.Panics(t, func() { NewLimiter(tc.rate, tc.burst) }, "rate=%d burst=%d", tc.rate, tc.burst)
+	}
+	require.NotPanics(t, func() { NewLimiter(1, 1) })
+}
@@ -0,0 +1,140 @@
+package wfs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path"
+)
+
+// AtomicFile is a file which is replaced atomically: readers see either the
+// old content or the new one, even after a crash. It's written to a temporary
+// file in the same directory, which Commit renames.
+//
+// Close discards the file unless Commit was called, so it can be deferred:
+//
+//	f, err := wfs.CreateAtomic(fsys, "config.json", 0644)
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//	if err := json.NewEncoder(f).Encode(cfg); err != nil {
+//		return err
+//	}
+//	return f.Commit()
+type AtomicFile struct {
+	fsys FS
+	name string
+	tmp  string
+	file File
+	perm fs.FileMode // Of the existing file, set on Commit
+	keep bool        // Keep perm
+	done bool
+}
+
+// ErrCommitted is returned by AtomicFile methods after Commit or Abort.
+var ErrCommitted = errors.New("atomic file already committed or aborted")
+
+// CreateAtomic returns a new AtomicFile for name. If name exists, the new file
+// keeps its permissions, otherwise it's created with perm (before umask).
+func CreateAtomic(fsys FS, name string, perm fs.FileMode) (*AtomicFile, error) {
+	a := AtomicFile{fsys: fsys, name: name}
+	if info, err := fs.Stat(fsys, name); err == nil {
+		if !info.Mode().IsRegular() {
+			return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrInvalid}
+		}
+		perm, a.perm, a.keep = info.Mode().Perm(), info.Mode().Perm(), true
+	}
+
+	// Hidden, so it doesn't match the patterns of name readers
+	dir, base := path.Split(name)
+	for i := 0; ; i++ {
+		a.tmp = fmt.Sprintf("%s.%s.%d.tmp", dir, base, rand.Uint32())
+		file, err := fsys.OpenFile(a.tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if err == nil {
+			a.file = file
+			return &a, nil
+		}
+		if !errors.Is(err, fs.ErrExist) || i == 10 {
+			return nil, err
+		}
+	}
+}
+
+// Name returns the name of the file, once committed.
+func (a *AtomicFile) Name() string {
+	return a.name
+}
+
+// Write implements io.Writer
+func (a *AtomicFile) Write(data []byte) (int, error) {
+	if a.done {
+		return 0, ErrCommitted
+	}
+	return a.file.Write(data)
+}
+
+// Commit syncs the written data, renames the temporary file to the file name
+// and syncs the directory. If it fails before the rename, the temporary file
+// is removed and the file is unchanged.
+func (a *AtomicFile) Commit() error {
+	if a.done {
+		return ErrCommitted
+	}
+	a.done = true
+
+	err := a.file.Sync()
+	if cerr := a.file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && a.keep {
+		// The umask might have changed the permissions
+		err = a.fsys.Chmod(a.tmp, a.perm)
+	}
+	if err == nil {
+		err = a.fsys.Rename(a.tmp, a.name)
+	}
+	if err != nil {
+		a.fsys.Remove(a.tmp)
+		return err
+	}
+
+	return syncDir(a.fsys, path.Dir(a.name))
+}
+
+// Abort discards the written data, the file is unchanged.
+func (a *AtomicFile) Abort() error {
+	if a.done {
+		return ErrCommitted
+	}
+	a.done = true
+
+	a.file.Close()
+	return a.fsys.Remove(a.tmp)
+}
+
+// Close implements io.Closer, it aborts unless Commit or Abort were called.
+func (a *AtomicFile) Close() error {
+	if a.done {
+		return nil
+	}
+	return a.Abort()
+}
+
+// syncDir syncs the directory dir, so a rename in it is durable. File
+// systems with directories which can't be synced are skipped.
+func syncDir(fsys FS, dir string) error {
+	d, err := fsys.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	s, ok := d.(interface{ Sync() error })
+	if !ok {
+		return nil
+	}
+	return s.Sync()
+}
@@ -0,0 +1,144 @@
+package wfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goiface/3_io/faultio"
+)
+
+func writeAtomic(fsys FS, name, data string) error {
+	f, err := CreateAtomic(fsys, name, 0640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.WriteString(f, data); err != nil {
+		return err
+	}
+	return f.Commit()
+}
+
+// names returns the names of the files in dir.
+func names(t *testing.T, fsys FS, dir string) []string {
+	entries, err := fs.ReadDir(fsys, dir)
+	require.NoError(t, err)
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Name())
+	}
+	return out
+}
+
+func TestAtomicFile(t *testing.T) {
+	for name, fsys := range map[string]FS{"mem": NewMemFS(), "dir": DirFS(t.TempDir())} {
+		t.Run(name, func(t *testing.T) {
+			fill(t, fsys)
+
+			f, err := CreateAtomic(fsys, "logs/state.json", 0640)
+			require.NoError(t, err)
+			_, err = io.WriteString(f, "{}\n")
+			require.NoError(t, err)
+
+			// Not visible before Commit
+			_, err = fs.Stat(fsys, "logs/state.json")
+			require.ErrorIs(t, err, fs.ErrNotExist)
+
+			require.NoError(t, f.Commit())
+			data, err := fs.ReadFile(fsys, "logs/state.json")
+			require.NoError(t, err)
+			require.Equal(t, "{}\n", string(data))
+			require.Equal(t, []string{"log-01.txt", "old", "state.json"}, names(t, fsys, "logs"))
+
+			require.ErrorIs(t, f.Commit(), ErrCommitted)
+			require.ErrorIs(t, f.Abort(), ErrCommitted)
+			_, err = f.Write([]byte("x"))
+			require.ErrorIs(t, err, ErrCommitted)
+			require.NoError(t, f.Close())
+
+			// Abort keeps the old content
+			f, err = CreateAtomic(fsys, "logs/log-01.txt", 0666)
+			require.NoError(t, err)
+			io.WriteString(f, "Go Sucks!\n")
+			require.NoError(t, f.Abort())
+			data, err = fs.ReadFile(fsys, "logs/log-01.txt")
+			require.NoError(t, err)
+			require.Equal(t, "Go Rocks!\n", string(data))
+			require.Equal(t, []string{"log-01.txt", "old", "state.json"}, names(t, fsys, "logs"))
+
+			_, err = CreateAtomic(fsys, "logs/old", 0666)
+			require.ErrorIs(t, err, fs.ErrInvalid)
+			_, err = CreateAtomic(fsys, "missing/state.json", 0666)
+			require.ErrorIs(t, err, fs.ErrNotExist)
+		})
+	}
+}
+
+func TestAtomicFile_Perm(t *testing.T) {
+	dir := t.TempDir()
+	fsys := DirFS(dir)
+
+	// New files get perm minus the umask, like os.Create
+	require.NoError(t, writeAtomic(fsys, "a.txt", "a"))
+	info, err := os.Stat(filepath.Join(dir, "a.txt"))
+	require.NoError(t, err)
+	require.Zero(t, info.Mode().Perm()&^0640)
+
+	// Existing files keep theirs, even the bits masked by the umask
+	require.NoError(t, os.Chmod(filepath.Join(dir, "a.txt"), 0666))
+	require.NoError(t, writeAtomic(fsys, "a.txt", "b"))
+	info, err = os.Stat(filepath.Join(dir, "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, fs.FileMode(0666), info.Mode().Perm())
+
+	mem := NewMemFS()
+	require.NoError(t, WriteFile(mem, "b.txt", nil, 0604))
+	require.NoError(t, writeAtomic(mem, "b.txt", "b"))
+	info, err = mem.Stat("b.txt")
+	require.NoError(t, err)
+	require.Equal(t, fs.FileMode(0604), info.Mode().Perm())
+}
+
+func TestAtomicFile_Faults(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fault   Fault
+		renamed bool // Failed after the rename
+	}{
+		{"create", Fault{Op: OpCreate, Name: "logs/.log-01.txt.*"}, false},
+		{"write", Fault{Op: OpWrite, After: 3}, false},
+		{"sync", Fault{Op: OpSync, Name: "logs/.log-01.txt.*"}, false},
+		{"close", Fault{Op: OpClose}, false},
+		{"chmod", Fault{Op: OpChmod}, false},
+		{"rename", Fault{Op: OpRename}, false},
+		{"sync dir", Fault{Op: OpSync, Name: "logs"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsys := NewMemFS()
+			fill(t, fsys)
+			fsys.Inject(tc.fault)
+
+			err := writeAtomic(fsys, "logs/log-01.txt", "Go Sucks!\n")
+			require.ErrorIs(t, err, faultio.ErrInjected)
+
+			fsys.Reset()
+			data, err := fs.ReadFile(fsys, "logs/log-01.txt")
+			require.NoError(t, err)
+			if tc.renamed {
+				require.Equal(t, "Go Sucks!\n", string(data))
+			} else {
+				require.Equal(t, "Go Rocks!\n", string(data))
+			}
+			// No temporary file left
+			require.Equal(t, []string{"log-01.txt", "old"}, names(t, fsys, "logs"))
+		})
+	}
+}
@@ -0,0 +1,450 @@
+package wfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+
+	"goiface/3_io/faultio"
+)
+
+// Op is a file system operation, used to inject faults.
+type Op string
+
+const (
+	OpOpen   Op = "open"   // Open or OpenFile without os.O_CREATE
+	OpCreate Op = "create" // OpenFile with os.O_CREATE
+	OpMkdir  Op = "mkdir"
+	OpRemove Op = "remove"
+	OpRename Op = "rename" // Matched against the old name
+	OpChmod  Op = "chmod"
+	OpWrite  Op = "write"
+	OpSync   Op = "sync" // File or directory
+	OpClose  Op = "close"
+)
+
+// Fault makes operations fail.
+type Fault struct {
+	Op    Op     // "" matches every operation
+	Name  string // path.Match pattern, "" matches every name
+	After int64  // OpWrite fails once the file has After bytes
+	Err   error  // defaults to faultio.ErrInjected
+}
+
+func (f Fault) match(op Op, name string) bool {
+	if f.Op != "" && f.Op != op {
+		return false
+	}
+	if f.Name == "" {
+		return true
+	}
+	ok, _ := path.Match(f.Name, name)
+	return ok
+}
+
+// MemFS is an in memory file system, safe for concurrent use.
+type MemFS struct {
+	mu     sync.Mutex
+	files  fstest.MapFS
+	faults []Fault
+}
+
+// NewMemFS returns an empty in memory file system.
+func NewMemFS() *MemFS {
+	m := MemFS{
+		files: fstest.MapFS{},
+	}
+	return &m
+}
+
+// Inject adds a fault, faults are checked in the order they were added.
+func (m *MemFS) Inject(f Fault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if f.Err == nil {
+		f.Err = faultio.ErrInjected
+	}
+	m.faults = append(m.faults, f)
+}
+
+// Reset removes all the faults.
+func (m *MemFS) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.faults = nil
+}
+
+// fault returns the error of the first fault matching op & name, m.mu must be
+// held.
+func (m *MemFS) fault(op Op, name string) error {
+	for _, f := range m.faults {
+		if f.match(op, name) {
+			return &fs.PathError{Op: string(op), Path: name, Err: f.Err}
+		}
+	}
+
+	return nil
+}
+
+// writeLimit returns the smallest OpWrite fault size and its error, m.mu
+// must be held.
+func (m *MemFS) writeLimit(name string) (int64, error) {
+	var limit int64
+	var err error
+	for _, f := range m.faults {
+		if !f.match(OpWrite, name) {
+			continue
+		}
+		if err == nil || f.After < limit {
+			limit = f.After
+			err = &fs.PathError{Op: string(OpWrite), Path: name, Err: f.Err}
+		}
+	}
+
+	return limit, err
+}
+
+func (m *MemFS) isDir(name string) bool {
+	if name == "." {
+		return true
+	}
+	f, ok := m.files[name]
+	return ok && f.Mode.IsDir()
+}
+
+// Open implements fs.FS, a regular file is a snapshot of its content.
+func (m *MemFS) Open(name string) (fs.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if err := m.fault(OpOpen, name); err != nil {
+		return nil, err
+	}
+
+	f, ok := m.files[name]
+	if !ok || f.Mode.IsDir() {
+		file, err := m.files.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		return &memDir{ReadDirFile: file.(fs.ReadDirFile), fs: m, name: name}, nil
+	}
+
+	snap := *f
+	snap.Data = append([]byte(nil), f.Data...)
+	base := path.Base(name)
+	return fstest.MapFS{base: &snap}.Open(base)
+}
+
+// ReadDir implements fs.ReadDirFS
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fault(OpOpen, name); err != nil {
+		return nil, err
+	}
+	return m.files.ReadDir(name)
+}
+
+// Stat implements fs.StatFS
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, ok := m.files[name]
+	if !ok || f.Mode.IsDir() {
+		return m.files.Stat(name)
+	}
+	return statSnapshot(name, f)
+}
+
+// statSnapshot returns the FileInfo of a copy of f, so it doesn't change with
+// later writes.
+func statSnapshot(name string, f *fstest.MapFile) (fs.FileInfo, error) {
+	base := path.Base(name)
+	snap := *f
+	snap.Data = nil
+	info, err := fstest.MapFS{base: &snap}.Stat(base)
+	if err != nil {
+		return nil, err
+	}
+	return sizedInfo{info, int64(len(f.Data))}, nil
+}
+
+type sizedInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (i sizedInfo) Size() int64 {
+	return i.size
+}
+
+// MkdirAll implements FS
+func (m *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if err := m.fault(OpMkdir, name); err != nil {
+		return err
+	}
+
+	for dir := name; dir != "."; dir = path.Dir(dir) {
+		if f, ok := m.files[dir]; ok {
+			if !f.Mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: dir, Err: errors.New("not a directory")}
+			}
+			continue
+		}
+		m.files[dir] = &fstest.MapFile{Mode: fs.ModeDir | perm.Perm(), ModTime: time.Now()}
+	}
+
+	return nil
+}
+
+// OpenFile implements FS
+func (m *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	op := OpOpen
+	if flag&os.O_CREATE != 0 {
+		op = OpCreate
+	}
+	if err := m.fault(op, name); err != nil {
+		return nil, err
+	}
+
+	f, ok := m.files[name]
+	switch {
+	case ok && f.Mode.IsDir():
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+	case ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case !ok:
+		if !m.isDir(path.Dir(name)) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		f = &fstest.MapFile{Mode: perm.Perm(), ModTime: time.Now()}
+		m.files[name] = f
+	}
+
+	if flag&os.O_TRUNC != 0 {
+		f.Data = nil
+	}
+
+	mf := memFile{
+		fs:   m,
+		name: name,
+		f:    f,
+		flag: flag,
+	}
+	return &mf, nil
+}
+
+// Remove implements FS
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fault(OpRemove, name); err != nil {
+		return err
+	}
+
+	f, ok := m.files[name]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if f.Mode.IsDir() {
+		prefix := name + "/"
+		for other := range m.files {
+			if strings.HasPrefix(other, prefix) {
+				return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+			}
+		}
+	}
+
+	delete(m.files, name)
+	return nil
+}
+
+// Rename implements FS
+func (m *MemFS) Rename(oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fault(OpRename, oldName); err != nil {
+		return err
+	}
+
+	f, ok := m.files[oldName]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: oldName, Err: fs.ErrNotExist}
+	}
+	if !m.isDir(path.Dir(newName)) || m.isDir(newName) {
+		return &fs.PathError{Op: "rename", Path: newName, Err: fs.ErrInvalid}
+	}
+
+	delete(m.files, oldName)
+	m.files[newName] = f
+
+	if f.Mode.IsDir() {
+		prefix := oldName + "/"
+		for other, of := range m.files {
+			if strings.HasPrefix(other, prefix) {
+				delete(m.files, other)
+				m.files[newName+"/"+strings.TrimPrefix(other, prefix)] = of
+			}
+		}
+	}
+
+	return nil
+}
+
+// Chmod implements FS
+func (m *MemFS) Chmod(name string, mode fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fault(OpChmod, name); err != nil {
+		return err
+	}
+
+	f, ok := m.files[name]
+	if !ok {
+		return &fs.PathError{Op: "chmod", Path: name, Err: fs.ErrNotExist}
+	}
+	f.Mode = f.Mode&^fs.ModePerm | mode.Perm()
+	return nil
+}
+
+// memDir is an open MemFS directory, it can be synced like an *os.File to
+// inject OpSync faults.
+type memDir struct {
+	fs.ReadDirFile
+	fs   *MemFS
+	name string
+}
+
+func (d *memDir) Sync() error {
+	d.fs.mu.Lock()
+	defer d.fs.mu.Unlock()
+
+	return d.fs.fault(OpSync, d.name)
+}
+
+// memFile is an open MemFS file.
+type memFile struct {
+	fs     *MemFS
+	name   string
+	f      *fstest.MapFile
+	flag   int
+	offset int64
+	closed bool
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	return statSnapshot(f.name, f.f)
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrClosed}
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == os.O_WRONLY {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrPermission}
+	}
+
+	if f.offset >= int64(len(f.f.Data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.f.Data[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Write(data []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrClosed}
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrPermission}
+	}
+
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.f.Data))
+	}
+
+	// Write up to the first OpWrite fault
+	var err error
+	if limit, ferr := f.fs.writeLimit(f.name); ferr != nil {
+		if f.offset >= limit {
+			return 0, ferr
+		}
+		if f.offset+int64(len(data)) > limit {
+			data, err = data[:limit-f.offset], ferr
+		}
+	}
+
+	end := f.offset + int64(len(data))
+	if end > int64(len(f.f.Data)) {
+		f.f.Data = append(f.f.Data, make([]byte, end-int64(len(f.f.Data)))...)
+	}
+	copy(f.f.Data[f.offset:], data)
+	f.offset = end
+	f.f.ModTime = time.Now()
+
+	return len(data), err
+}
+
+func (f *memFile) Sync() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	return f.fs.fault(OpSync, f.name)
+}
+
+func (f *memFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+	f.closed = true
+
+	return f.fs.fault(OpClose, f.name)
+}
@@ -0,0 +1,129 @@
+// Package wfs extends io/fs with writable file systems.
+package wfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// File is a writable file.
+type File interface {
+	fs.File
+	io.Writer
+	Sync() error
+}
+
+// FS is a writable file system, names follow the fs.ValidPath rules.
+type FS interface {
+	fs.FS
+	MkdirAll(name string, perm fs.FileMode) error
+	// OpenFile is like os.OpenFile.
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error)
+	Remove(name string) error
+	Rename(oldName, newName string) error
+	Chmod(name string, mode fs.FileMode) error
+}
+
+// Create creates or truncates the named file, like os.Create.
+func Create(fsys FS, name string) (File, error) {
+	return fsys.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// WriteFile writes data to the named file, like os.WriteFile.
+func WriteFile(fsys FS, name string, data []byte, perm fs.FileMode) error {
+	f, err := fsys.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// DirFS returns a file system for the tree of files rooted at dir on the
+// operating system file system.
+func DirFS(dir string) FS {
+	return dirFS(dir)
+}
+
+type dirFS string
+
+func (d dirFS) join(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(string(d), filepath.FromSlash(name)), nil
+}
+
+func (d dirFS) Open(name string) (fs.File, error) {
+	path, err := d.join("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (d dirFS) Stat(name string) (fs.FileInfo, error) {
+	path, err := d.join("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(path)
+}
+
+func (d dirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	path, err := d.join("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(path)
+}
+
+func (d dirFS) MkdirAll(name string, perm fs.FileMode) error {
+	path, err := d.join("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(path, perm)
+}
+
+func (d dirFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	path, err := d.join("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, flag, perm)
+}
+
+func (d dirFS) Remove(name string) error {
+	path, err := d.join("remove", name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (d dirFS) Rename(oldName, newName string) error {
+	oldPath, err := d.join("rename", oldName)
+	if err != nil {
+		return err
+	}
+	newPath, err := d.join("rename", newName)
+	if err != nil {
+		return err
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+func (d dirFS) Chmod(name string, mode fs.FileMode) error {
+	path, err := d.join("chmod", name)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(path, mode)
+}
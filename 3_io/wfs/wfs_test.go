@@ -0,0 +1,93 @@
+package wfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"goiface/3_io/faultio"
+)
+
+func fill(t *testing.T, fsys FS) {
+	require.NoError(t, fsys.MkdirAll("logs/old", 0700))
+	require.NoError(t, WriteFile(fsys, "logs/log-01.txt", []byte("Go Rocks!\n"), 0600))
+	require.NoError(t, WriteFile(fsys, "logs/old/log-00.txt", []byte("Go Rules!\n"), 0600))
+}
+
+func TestFS(t *testing.T) {
+	for name, fsys := range map[string]FS{"mem": NewMemFS(), "dir": DirFS(t.TempDir())} {
+		t.Run(name, func(t *testing.T) {
+			fill(t, fsys)
+			require.NoError(t, fstest.TestFS(fsys, "logs/log-01.txt", "logs/old/log-00.txt"))
+
+			require.NoError(t, fsys.Rename("logs/log-01.txt", "logs/log-02.txt"))
+			data, err := fs.ReadFile(fsys, "logs/log-02.txt")
+			require.NoError(t, err)
+			require.Equal(t, "Go Rocks!\n", string(data))
+
+			require.Error(t, fsys.Remove("logs/old"), "not empty")
+			require.NoError(t, fsys.Remove("logs/old/log-00.txt"))
+			require.NoError(t, fsys.Remove("logs/old"))
+
+			_, err = fsys.Open("logs/old")
+			require.ErrorIs(t, err, fs.ErrNotExist)
+			_, err = fsys.Open("/etc/passwd")
+			require.ErrorIs(t, err, fs.ErrInvalid)
+			_, err = Create(fsys, "nodir/file.txt")
+			require.ErrorIs(t, err, fs.ErrNotExist)
+		})
+	}
+}
+
+func TestMemFS_Append(t *testing.T) {
+	m := NewMemFS()
+	require.NoError(t, WriteFile(m, "a.txt", []byte("abc"), 0600))
+
+	f, err := m.OpenFile("a.txt", os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("def"))
+	require.NoError(t, err)
+	_, err = f.Read(make([]byte, 3))
+	require.ErrorIs(t, err, fs.ErrPermission)
+	require.NoError(t, f.Close())
+	require.ErrorIs(t, f.Close(), fs.ErrClosed)
+
+	info, err := m.Stat("a.txt")
+	require.NoError(t, err)
+	require.Equal(t, int64(6), info.Size())
+	require.Equal(t, fs.FileMode(0600), info.Mode())
+}
+
+func TestMemFS_Faults(t *testing.T) {
+	m := NewMemFS()
+	require.NoError(t, m.MkdirAll("logs", 0700))
+
+	errFull := errors.New("disk full")
+	m.Inject(Fault{Op: OpWrite, Name: "logs/*.txt", After: 4, Err: errFull})
+	m.Inject(Fault{Op: OpClose, Name: "logs/b.txt"})
+	m.Inject(Fault{Op: OpCreate, Name: "logs/c.txt"})
+
+	f, err := Create(m, "logs/a.txt")
+	require.NoError(t, err)
+	n, err := io.WriteString(f, "Go Rocks!")
+	require.ErrorIs(t, err, errFull)
+	require.Equal(t, 4, n)
+	_, err = io.WriteString(f, "!")
+	require.ErrorIs(t, err, errFull)
+	require.NoError(t, f.Close())
+
+	f, err = Create(m, "logs/b.txt")
+	require.NoError(t, err)
+	require.ErrorIs(t, f.Close(), faultio.ErrInjected)
+
+	_, err = Create(m, "logs/c.txt")
+	require.ErrorIs(t, err, faultio.ErrInjected)
+
+	m.Reset()
+	require.NoError(t, WriteFile(m, "logs/c.txt", []byte("Go Rocks!"), 0600))
+}
@@ -59,6 +59,11 @@ func (e *Error) Error() string {
 	return e.cause.Error()
 }
 
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
 func (e *Error) Format(f fmt.State, verb rune) {
 	if verb == 'v' && f.Flag('+') {
 		fmt.Fprint(f, e.stack)
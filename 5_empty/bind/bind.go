@@ -0,0 +1,352 @@
+package bind
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"goiface/5_empty/conv"
+)
+
+// Error is a bind error at a given path (e.g. "user.ports[1]").
+type Error struct {
+	Path string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Bind fills the struct pointed by v from m.
+// Fields are matched by their json tag name (or field name), exact matches
+// first then case insensitive. Values are converted with conv.Convert, nested
+// maps fill nested structs and fields of embedded structs are promoted with
+// the encoding/json rules.
+// time.Time values are parsed from RFC 3339 strings or Unix seconds and
+// time.Duration from strings such as "1m30s".
+// Keys without a matching field are ignored.
+func Bind(m map[string]any, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("%T - not a pointer", v)
+	}
+
+	if rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%T - not a pointer to struct", v)
+	}
+
+	return bindStruct(reflect.ValueOf(m), rv.Elem(), "")
+}
+
+func bindValue(src, dest reflect.Value, path string) error {
+	for src.IsValid() && src.Kind() == reflect.Interface {
+		src = src.Elem()
+	}
+
+	if !src.IsValid() {
+		// nil, leave dest as is
+		return nil
+	}
+
+	switch typ := dest.Type(); {
+	case typ == timeType:
+		t, err := toTime(src)
+		if err != nil {
+			return &Error{path, err}
+		}
+		dest.Set(reflect.ValueOf(t))
+		return nil
+	case typ == durationType && src.Kind() == reflect.String:
+		d, err := time.ParseDuration(src.String())
+		if err != nil {
+			return &Error{path, err}
+		}
+		dest.SetInt(int64(d))
+		return nil
+	}
+
+	switch dest.Kind() {
+	case reflect.Struct:
+		return bindStruct(src, dest, path)
+	case reflect.Pointer:
+		if dest.IsNil() {
+			dest.Set(reflect.New(dest.Type().Elem()))
+		}
+		return bindValue(src, dest.Elem(), path)
+	case reflect.Slice:
+		if dest.Type().Elem().Kind() == reflect.Uint8 {
+			break // []byte
+		}
+		return bindSlice(src, dest, path)
+	case reflect.Map:
+		return bindMap(src, dest, path)
+	}
+
+	out, err := conv.Convert(src.Interface(), dest.Type())
+	if err != nil {
+		return &Error{path, err}
+	}
+	dest.Set(out)
+	return nil
+}
+
+func bindStruct(src, dest reflect.Value, path string) error {
+	if src.Kind() != reflect.Map || src.Type().Key().Kind() != reflect.String {
+		return &Error{path, fmt.Errorf("%s - can't bind to %s", src.Type(), dest.Type())}
+	}
+
+	fields := structFields(dest.Type())
+	keys := matchKeys(src, fields)
+	// In declaration order, so the first error is always the same
+	for i, f := range fields {
+		key, ok := keys[i]
+		if !ok {
+			continue
+		}
+		fv, ok := fieldByIndex(dest, f.index)
+		if !ok {
+			continue
+		}
+
+		if err := bindValue(src.MapIndex(key), fv, joinPath(path, key.String())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func bindSlice(src, dest reflect.Value, path string) error {
+	if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+		return &Error{path, fmt.Errorf("%s - can't bind to %s", src.Type(), dest.Type())}
+	}
+
+	out := reflect.MakeSlice(dest.Type(), src.Len(), src.Len())
+	for i := 0; i < src.Len(); i++ {
+		if err := bindValue(src.Index(i), out.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+
+	dest.Set(out)
+	return nil
+}
+
+func bindMap(src, dest reflect.Value, path string) error {
+	if src.Kind() != reflect.Map {
+		return &Error{path, fmt.Errorf("%s - can't bind to %s", src.Type(), dest.Type())}
+	}
+
+	typ := dest.Type()
+	out := reflect.MakeMapWithSize(typ, src.Len())
+	iter := src.MapRange()
+	for iter.Next() {
+		elemPath := fmt.Sprintf("%s[%v]", path, iter.Key())
+		key, err := conv.Convert(iter.Key().Interface(), typ.Key())
+		if err != nil {
+			return &Error{elemPath, err}
+		}
+
+		val := reflect.New(typ.Elem()).Elem()
+		if err := bindValue(iter.Value(), val, elemPath); err != nil {
+			return err
+		}
+		out.SetMapIndex(key, val)
+	}
+
+	dest.Set(out)
+	return nil
+}
+
+func toTime(v reflect.Value) (time.Time, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return time.Parse(time.RFC3339Nano, v.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		secs, err := conv.Convert(v.Interface(), reflect.TypeOf(int64(0)))
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(secs.Int(), 0).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%s - can't convert to time", v.Type())
+}
+
+// field is a struct field to bind, including fields promoted from embedded
+// structs.
+type field struct {
+	name   string
+	tagged bool  // name is from the json tag
+	index  []int // See reflect.Value.FieldByIndex
+}
+
+// structFields returns the fields of typ in declaration order. Like Go field
+// promotion and encoding/json, the shallowest field with a name hides the
+// deeper ones, and fields with the same name at the same depth are ignored
+// unless only one of them is tagged.
+func structFields(typ reflect.Type) []field {
+	var all []field
+	seen := make(map[reflect.Type]bool) // Embedded struct cycles
+	var walk func(typ reflect.Type, index []int)
+	walk = func(typ reflect.Type, index []int) {
+		if seen[typ] {
+			return
+		}
+		seen[typ] = true
+		defer delete(seen, typ)
+
+		for i := 0; i < typ.NumField(); i++ {
+			sf := typ.Field(i)
+			name, ok := fieldName(sf)
+			if !ok {
+				continue
+			}
+
+			fi := append(slices.Clip(index), i)
+			if name == "" { // embedded struct
+				t := sf.Type
+				if t.Kind() == reflect.Pointer {
+					t = t.Elem()
+				}
+				walk(t, fi)
+				continue
+			}
+
+			tag, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			all = append(all, field{name: name, tagged: tag != "", index: fi})
+		}
+	}
+	walk(typ, nil)
+
+	fields := make([]field, 0, len(all))
+	for _, f := range all {
+		if dominant(f, all) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// dominant returns true if f is not hidden by another field of all with the
+// same name.
+func dominant(f field, all []field) bool {
+	for _, g := range all {
+		if g.name != f.name || slices.Equal(g.index, f.index) {
+			continue
+		}
+		if len(g.index) < len(f.index) {
+			return false
+		}
+		if len(g.index) == len(f.index) && (!f.tagged || g.tagged) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchKeys returns the key of src matching each field, by index in fields.
+// Exact name matches win over case insensitive ones, keys are tried in sorted
+// order.
+func matchKeys(src reflect.Value, fields []field) map[int]reflect.Value {
+	byName := make(map[string]reflect.Value, src.Len())
+	names := make([]string, 0, src.Len())
+	iter := src.MapRange()
+	for iter.Next() {
+		name := iter.Key().String()
+		byName[name] = iter.Key()
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	keys := make(map[int]reflect.Value)
+	exact := make(map[string]bool)
+	for i, f := range fields {
+		if key, ok := byName[f.name]; ok {
+			keys[i] = key
+			exact[f.name] = true
+		}
+	}
+
+	for _, name := range names {
+		if exact[name] {
+			continue
+		}
+		for i, f := range fields {
+			if _, ok := keys[i]; !ok && strings.EqualFold(f.name, name) {
+				keys[i] = byName[name]
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// fieldByIndex returns the field of v at index, allocating nil embedded
+// pointers on the way. It returns false if one can't be set.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldName returns the field bind name, "" for embedded structs to
+// flatten. It returns false for fields to skip.
+func fieldName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+
+	if sf.Anonymous && name == "" {
+		t := sf.Type
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			return "", true
+		}
+	}
+
+	if !sf.IsExported() {
+		return "", false
+	}
+
+	if name == "" {
+		name = sf.Name
+	}
+	return name, true
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
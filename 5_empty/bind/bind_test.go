@@ -0,0 +1,160 @@
+package bind
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goiface/5_empty/conv"
+)
+
+type Base struct {
+	ID uint `json:"id"`
+}
+
+type Meta struct {
+	Tags map[string]int `json:"tags"`
+}
+
+type Record struct {
+	Base
+	*Meta
+	Login   string
+	Secret  string    `json:"-"`
+	Parent  *Record   `json:"parent"`
+	Scores  []float64 `json:"scores"`
+	Created time.Time `json:"created"`
+	hidden  int
+}
+
+func TestBind(t *testing.T) {
+	m := map[string]any{
+		"id":      float64(7),
+		"login":   "joe",
+		"Secret":  "s3cr3t",
+		"parent":  map[string]any{"id": "1", "login": "root"},
+		"scores":  []any{1, "2.5"},
+		"created": 1707683251,
+		"tags":    map[string]any{"a": "1"},
+		"hidden":  3,
+		"unknown": true,
+	}
+
+	var r Record
+	require.NoError(t, Bind(m, &r))
+
+	require.Equal(t, uint(7), r.ID)
+	require.Equal(t, "joe", r.Login)
+	require.Equal(t, "", r.Secret)
+	require.Equal(t, 0, r.hidden)
+	require.NotNil(t, r.Parent)
+	require.Equal(t, uint(1), r.Parent.ID)
+	require.Equal(t, "root", r.Parent.Login)
+	require.Nil(t, r.Parent.Meta)
+	require.Equal(t, []float64{1, 2.5}, r.Scores)
+	require.Equal(t, time.Date(2024, 2, 11, 20, 27, 31, 0, time.UTC), r.Created)
+	require.Equal(t, map[string]int{"a": 1}, r.Meta.Tags)
+}
+
+func TestBind_Errors(t *testing.T) {
+	var r Record
+	err := Bind(map[string]any{"parent": map[string]any{"scores": []any{1, "x"}}}, &r)
+	require.EqualError(t, err, `parent.scores[1]: can't convert "x" (string) to float64 - invalid syntax`)
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+
+	var cerr *conv.Error
+	require.True(t, errors.As(err, &cerr))
+
+	err = Bind(map[string]any{"created": "yesterday"}, &r)
+	var berr *Error
+	require.True(t, errors.As(err, &berr))
+	require.Equal(t, "created", berr.Path)
+
+	err = Bind(map[string]any{"parent": 3}, &r)
+	require.EqualError(t, err, "parent: int - can't bind to bind.Record")
+
+	require.Error(t, Bind(nil, r))
+	var n int
+	require.Error(t, Bind(nil, &n))
+}
+
+type Named struct {
+	Name string
+}
+
+type Label struct {
+	Name string
+}
+
+type Title struct {
+	Name string `json:"name"`
+}
+
+func TestBind_Promotion(t *testing.T) {
+	// The outer ID hides Base.ID
+	var shadow struct {
+		Base
+		ID string `json:"id"`
+	}
+	require.NoError(t, Bind(map[string]any{"id": "x7"}, &shadow))
+	require.Equal(t, "x7", shadow.ID)
+	require.Zero(t, shadow.Base.ID)
+
+	// Ambiguous at the same depth, unless only one is tagged
+	var ambiguous struct {
+		Named
+		Label
+	}
+	require.NoError(t, Bind(map[string]any{"name": "joe"}, &ambiguous))
+	require.Equal(t, "", ambiguous.Named.Name)
+	require.Equal(t, "", ambiguous.Label.Name)
+
+	var tagged struct {
+		Named
+		*Title
+	}
+	require.NoError(t, Bind(map[string]any{"name": "joe"}, &tagged))
+	require.Equal(t, "", tagged.Named.Name)
+	require.Equal(t, "joe", tagged.Title.Name)
+
+	// Nil embedded pointers are allocated only if a field is bound
+	var alloc struct {
+		Named
+		*Record
+	}
+	require.NoError(t, Bind(map[string]any{"Name": "joe"}, &alloc))
+	require.Nil(t, alloc.Record)
+	require.NoError(t, Bind(map[string]any{"id": 7}, &alloc))
+	require.Equal(t, uint(7), alloc.Record.ID)
+}
+
+func TestBind_ExactMatch(t *testing.T) {
+	var v struct {
+		ID string
+		Id string
+	}
+	m := map[string]any{"Id": "exact", "ID": "other", "id": "fold"}
+	require.NoError(t, Bind(m, &v))
+	require.Equal(t, "other", v.ID)
+	require.Equal(t, "exact", v.Id)
+
+	var fold struct {
+		Login string
+	}
+	require.NoError(t, Bind(map[string]any{"LOGIN": "a", "login": "b"}, &fold))
+	require.Equal(t, "a", fold.Login, "first key in sorted order")
+}
+
+func TestBind_ErrorOrder(t *testing.T) {
+	m := map[string]any{"scores": []any{"x"}, "created": "yesterday", "id": "seven"}
+	for i := 0; i < 20; i++ {
+		var r Record
+		err := Bind(m, &r)
+		var berr *Error
+		require.True(t, errors.As(err, &berr))
+		require.Equal(t, "id", berr.Path, "first field in declaration order")
+	}
+}
@@ -0,0 +1,46 @@
+package bind
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type Address struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+type Config struct {
+	Name    string        `json:"name"`
+	Servers []Address     `json:"servers"`
+	Timeout time.Duration `json:"timeout"`
+	Started time.Time     `json:"started"`
+}
+
+func ExampleBind() {
+	data := []byte(`{
+		"name": "api",
+		"servers": [{"host": "localhost", "port": "8080"}],
+		"timeout": "1m30s",
+		"started": "2024-02-11T20:27:31Z"
+	}`)
+
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		fmt.Println("ERROR:", err)
+		return
+	}
+
+	var cfg Config
+	err := Bind(m, &cfg)
+	fmt.Printf("%+v, err: %v\n", cfg, err)
+
+	m["servers"] = []any{map[string]any{"port": "http"}}
+	err = Bind(m, &cfg)
+	fmt.Println("err:", err)
+
+	// Output:
+	// {Name:api Servers:[{Host:localhost Port:8080}] Timeout:1m30s Started:2024-02-11 20:27:31 +0000 UTC}, err: <nil>
+	// err: servers[0].port: can't convert "http" (string) to int - invalid syntax
+}
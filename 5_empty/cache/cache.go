@@ -0,0 +1,295 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"goiface/2_design/clock"
+)
+
+// Policy is the eviction policy used when the cache is full.
+type Policy byte
+
+const (
+	LRU Policy = iota + 1 // Least recently used
+	LFU                   // Least frequently used
+)
+
+// Stats are cache usage counters.
+type Stats struct {
+	Hits        int64 // Get/Load calls that found the key
+	Misses      int64 // Get/Load calls that didn't find the key
+	Loads       int64 // Loader calls
+	LoadErrors  int64 // Loader calls that failed
+	Evictions   int64 // Entries evicted to make room
+	Expirations int64 // Entries removed since their TTL passed
+}
+
+// Option configures a Cache.
+type Option func(*options)
+
+type options struct {
+	capacity int
+	policy   Policy
+	ttl      time.Duration
+	clock    clock.Clock
+}
+
+// WithCapacity sets the maximal number of entries, the default is unbounded.
+func WithCapacity(n int) Option {
+	return func(o *options) {
+		o.capacity = n
+	}
+}
+
+// WithPolicy sets the eviction policy, the default is LRU.
+func WithPolicy(p Policy) Option {
+	return func(o *options) {
+		o.policy = p
+	}
+}
+
+// WithTTL sets the default entry time to live, the default is no expiration.
+func WithTTL(d time.Duration) Option {
+	return func(o *options) {
+		o.ttl = d
+	}
+}
+
+// WithClock sets the clock used for TTL, the default is the system clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time // zero is no expiration
+
+	// Used by policies
+	elem  *list.Element // lru
+	freq  int           // lfu
+	seq   uint64        // lfu
+	index int           // lfu
+}
+
+// policy tracks entries and picks the one to evict.
+type policy[K comparable, V any] interface {
+	add(e *entry[K, V])
+	access(e *entry[K, V])
+	remove(e *entry[K, V])
+	victim() *entry[K, V]
+}
+
+// call is an in flight loader call.
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Cache is a key/value cache safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]*entry[K, V]
+	policy  policy[K, V]
+	calls   map[K]*call[V]
+	opts    options
+	stats   Stats
+	clock   clock.Clock
+}
+
+// New returns a new cache.
+func New[K comparable, V any](opts ...Option) *Cache[K, V] {
+	o := options{policy: LRU}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := Cache[K, V]{
+		entries: make(map[K]*entry[K, V]),
+		calls:   make(map[K]*call[V]),
+		opts:    o,
+		clock:   clock.Or(o.clock),
+	}
+
+	if o.policy == LFU {
+		c.policy = newLFU[K, V]()
+	} else {
+		c.policy = newLRU[K, V]()
+	}
+
+	return &c
+}
+
+// Get returns the value of key.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.get(key)
+}
+
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	e, ok := c.lookup(key)
+	if !ok {
+		c.stats.Misses++
+		var zero V
+		return zero, false
+	}
+
+	c.stats.Hits++
+	c.policy.access(e)
+	return e.value, true
+}
+
+// lookup returns the entry for key, removing it if expired.
+func (c *Cache[K, V]) lookup(key K) (*entry[K, V], bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if !e.expires.IsZero() && !c.clock.Now().Before(e.expires) {
+		c.remove(e)
+		c.stats.Expirations++
+		return nil, false
+	}
+
+	return e, true
+}
+
+// Set sets the value of key with the default TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetTTL(key, value, c.opts.ttl)
+}
+
+// SetTTL sets the value of key which expires after ttl, 0 is no expiration.
+func (c *Cache[K, V]) SetTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.calls, key) // A load in flight is stale, it's not cached
+	c.set(key, value, ttl)
+}
+
+func (c *Cache[K, V]) set(key K, value V, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.clock.Now().Add(ttl)
+	}
+
+	if e, ok := c.entries[key]; ok {
+		e.value = value
+		e.expires = expires
+		c.policy.access(e)
+		return
+	}
+
+	if c.opts.capacity > 0 && len(c.entries) >= c.opts.capacity {
+		if e := c.policy.victim(); e != nil {
+			c.remove(e)
+			c.stats.Evictions++
+		}
+	}
+
+	e := entry[K, V]{key: key, value: value, expires: expires}
+	c.entries[key] = &e
+	c.policy.add(&e)
+}
+
+// Delete deletes key from the cache.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.calls, key) // A load in flight is stale, it's not cached
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+}
+
+func (c *Cache[K, V]) remove(e *entry[K, V]) {
+	delete(c.entries, e.key)
+	c.policy.remove(e)
+}
+
+// Len returns the number of entries in the cache, including expired entries
+// not removed yet.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// Stats returns the cache usage counters.
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+// Load returns the value of key, calling load to get it if it's not in the
+// cache. Concurrent Load calls for the same key share a single load call.
+// load runs with a context that is not canceled when ctx is, Load returns
+// ctx.Err() if ctx is done before load finishes.
+// Errors are not cached, and neither are loaded values if key is set or
+// deleted while loading. A panic in load is returned as an error.
+func (c *Cache[K, V]) Load(ctx context.Context, key K, load func(context.Context, K) (V, error)) (V, error) {
+	c.mu.Lock()
+	if v, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return v, nil
+	}
+
+	cl, ok := c.calls[key]
+	if !ok {
+		cl = &call[V]{done: make(chan struct{})}
+		c.calls[key] = cl
+		c.stats.Loads++
+		go c.load(context.WithoutCancel(ctx), key, cl, load)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-cl.done:
+		return cl.value, cl.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (c *Cache[K, V]) load(ctx context.Context, key K, cl *call[V], load func(context.Context, K) (V, error)) {
+	defer close(cl.done)
+	func() {
+		// Fail the waiting Load calls instead of crashing
+		defer func() {
+			if r := recover(); r != nil {
+				cl.err = fmt.Errorf("cache: load %v panicked: %v", key, r)
+			}
+		}()
+		cl.value, cl.err = load(ctx, key)
+	}()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cl.err != nil {
+		c.stats.LoadErrors++
+	}
+	if c.calls[key] != cl {
+		return // Set or Delete while loading
+	}
+	delete(c.calls, key)
+	if cl.err == nil {
+		c.set(key, cl.value, c.opts.ttl)
+	}
+}
@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goiface/2_design/clock"
+)
+
+func TestCache_LRU(t *testing.T) {
+	c := New[string, int](WithCapacity(2))
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3) // evicts b
+
+	_, ok := c.Get("b")
+	require.False(t, ok)
+	v, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	require.Equal(t, 2, c.Len())
+	require.Equal(t, int64(1), c.Stats().Evictions)
+}
+
+func TestCache_LFU(t *testing.T) {
+	c := New[string, int](WithCapacity(2), WithPolicy(LFU))
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	c.Set("c", 3) // evicts b (2 uses vs 3)
+	c.Set("d", 4) // evicts c (1 use)
+
+	_, ok := c.Get("b")
+	require.False(t, ok)
+	_, ok = c.Get("c")
+	require.False(t, ok)
+	_, ok = c.Get("a")
+	require.True(t, ok)
+	_, ok = c.Get("d")
+	require.True(t, ok)
+}
+
+func TestCache_TTL(t *testing.T) {
+	now := time.Date(2024, 2, 11, 20, 27, 31, 0, time.UTC)
+	clk := clock.NewFake(now)
+	c := New[string, int](WithTTL(time.Minute), WithClock(clk))
+
+	c.Set("a", 1)
+	c.SetTTL("b", 2, time.Hour)
+	c.SetTTL("c", 3, 0)
+
+	clk.Advance(time.Minute)
+	_, ok := c.Get("a")
+	require.False(t, ok)
+	_, ok = c.Get("b")
+	require.True(t, ok)
+
+	clk.Advance(24 * time.Hour)
+	_, ok = c.Get("b")
+	require.False(t, ok)
+	_, ok = c.Get("c")
+	require.True(t, ok)
+
+	s := c.Stats()
+	require.Equal(t, int64(2), s.Expirations)
+	require.Equal(t, 1, c.Len())
+}
+
+func TestCache_LoadDedup(t *testing.T) {
+	c := New[int, string]()
+	var calls atomic.Int64
+	release := make(chan struct{})
+	load := func(ctx context.Context, key int) (string, error) {
+		calls.Add(1)
+		<-release
+		return fmt.Sprintf("v%d", key), nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([]string, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = c.Load(context.Background(), 7, load)
+		}(i)
+	}
+
+	// Let goroutines block on the load
+	require.Eventually(t, func() bool { return c.Stats().Misses == n }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int64(1), calls.Load())
+	for i, v := range results {
+		require.NoError(t, errs[i])
+		require.Equal(t, "v7", v)
+	}
+
+	v, ok := c.Get(7)
+	require.True(t, ok)
+	require.Equal(t, "v7", v)
+}
+
+func TestCache_LoadError(t *testing.T) {
+	c := New[int, int]()
+	load := func(ctx context.Context, key int) (int, error) {
+		return 0, fmt.Errorf("db down")
+	}
+
+	_, err := c.Load(context.Background(), 1, load)
+	require.Error(t, err)
+	require.Equal(t, 0, c.Len())
+	require.Equal(t, int64(1), c.Stats().LoadErrors)
+}
+
+func TestCache_LoadCanceled(t *testing.T) {
+	c := New[int, int]()
+	release := make(chan struct{})
+	loaded := make(chan error, 1)
+	load := func(ctx context.Context, key int) (int, error) {
+		<-release
+		loaded <- ctx.Err()
+		return 1, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.Load(ctx, 1, load)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// load goes on and fills the cache
+	close(release)
+	require.NoError(t, <-loaded)
+	require.Eventually(t, func() bool {
+		_, ok := c.Get(1)
+		return ok
+	}, time.Second, time.Millisecond)
+}
+
+func TestCache_LoadStale(t *testing.T) {
+	for _, op := range []string{"set", "delete"} {
+		t.Run(op, func(t *testing.T) {
+			c := New[int, string]()
+			started, release := make(chan struct{}), make(chan struct{})
+			load := func(ctx context.Context, key int) (string, error) {
+				close(started)
+				<-release
+				return "old", nil
+			}
+
+			done := make(chan string)
+			go func() {
+				v, _ := c.Load(context.Background(), 1, load)
+				done <- v
+			}()
+
+			<-started
+			if op == "set" {
+				c.Set(1, "new")
+			} else {
+				c.Delete(1)
+			}
+			close(release)
+			require.Equal(t, "old", <-done)
+
+			v, ok := c.Get(1)
+			if op == "set" {
+				require.True(t, ok)
+				require.Equal(t, "new", v)
+			} else {
+				require.False(t, ok)
+			}
+		})
+	}
+}
+
+func TestCache_LoadPanic(t *testing.T) {
+	c := New[int, int]()
+	load := func(ctx context.Context, key int) (int, error) {
+		panic("db exploded")
+	}
+
+	_, err := c.Load(context.Background(), 1, load)
+	require.ErrorContains(t, err, "db exploded")
+	require.Equal(t, 0, c.Len())
+	require.Equal(t, int64(1), c.Stats().LoadErrors)
+
+	// The failed call is not shared with later loads
+	v, err := c.Load(context.Background(), 1, func(context.Context, int) (int, error) { return 2, nil })
+	require.NoError(t, err)
+	require.Equal(t, 2, v)
+}
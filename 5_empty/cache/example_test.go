@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+)
+
+func ExampleCache_Load() {
+	c := New[string, int](WithCapacity(100))
+	load := func(ctx context.Context, login string) (int, error) {
+		fmt.Println("loading", login)
+		return len(login), nil // TODO: Query the database
+	}
+
+	for i := 0; i < 2; i++ {
+		v, err := c.Load(context.Background(), "elliot", load)
+		fmt.Println(v, err)
+	}
+	fmt.Printf("%+v\n", c.Stats())
+
+	// Output:
+	// loading elliot
+	// 6 <nil>
+	// 6 <nil>
+	// {Hits:1 Misses:1 Loads:1 LoadErrors:0 Evictions:0 Expirations:0}
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+// lru evicts the least recently used entry.
+type lru[K comparable, V any] struct {
+	order *list.List // front is most recent
+}
+
+func newLRU[K comparable, V any]() *lru[K, V] {
+	return &lru[K, V]{order: list.New()}
+}
+
+func (l *lru[K, V]) add(e *entry[K, V]) {
+	e.elem = l.order.PushFront(e)
+}
+
+func (l *lru[K, V]) access(e *entry[K, V]) {
+	l.order.MoveToFront(e.elem)
+}
+
+func (l *lru[K, V]) remove(e *entry[K, V]) {
+	l.order.Remove(e.elem)
+	e.elem = nil
+}
+
+func (l *lru[K, V]) victim() *entry[K, V] {
+	back := l.order.Back()
+	if back == nil {
+		return nil
+	}
+	return back.Value.(*entry[K, V])
+}
+
+// lfu evicts the least frequently used entry, the least recently used one on
+// ties.
+type lfu[K comparable, V any] struct {
+	entries lfuHeap[K, V]
+	seq     uint64
+}
+
+func newLFU[K comparable, V any]() *lfu[K, V] {
+	return &lfu[K, V]{}
+}
+
+func (l *lfu[K, V]) add(e *entry[K, V]) {
+	l.seq++
+	e.freq, e.seq = 1, l.seq
+	heap.Push(&l.entries, e)
+}
+
+func (l *lfu[K, V]) access(e *entry[K, V]) {
+	l.seq++
+	e.freq++
+	e.seq = l.seq
+	heap.Fix(&l.entries, e.index)
+}
+
+func (l *lfu[K, V]) remove(e *entry[K, V]) {
+	heap.Remove(&l.entries, e.index)
+}
+
+func (l *lfu[K, V]) victim() *entry[K, V] {
+	if len(l.entries) == 0 {
+		return nil
+	}
+	return l.entries[0]
+}
+
+// lfuHeap implements heap.Interface
+type lfuHeap[K comparable, V any] []*entry[K, V]
+
+func (h lfuHeap[K, V]) Len() int {
+	return len(h)
+}
+
+func (h lfuHeap[K, V]) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h lfuHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap[K, V]) Push(x any) {
+	e := x.(*entry[K, V])
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap[K, V]) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
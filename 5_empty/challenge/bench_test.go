@@ -0,0 +1,46 @@
+package pool
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+const benchSize = 1024
+
+func newBuf() *[]byte {
+	b := make([]byte, benchSize)
+	return &b
+}
+
+func BenchmarkSyncPool(b *testing.B) {
+	p := sync.Pool{New: func() any { return newBuf() }}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf := p.Get().(*[]byte)
+			p.Put(buf)
+		}
+	})
+}
+
+func benchPool(b *testing.B, opts ...Option) {
+	p := New(newBuf, opts...)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf, _ := p.Get()
+			p.Put(buf)
+		}
+	})
+}
+
+func BenchmarkPool(b *testing.B) {
+	benchPool(b)
+}
+
+func BenchmarkPool_Bounded(b *testing.B) {
+	benchPool(b, WithMaxSize(benchSize))
+}
+
+func BenchmarkPool_Sharded(b *testing.B) {
+	benchPool(b, WithShards(runtime.GOMAXPROCS(0)), WithMaxSize(benchSize))
+}
@@ -0,0 +1,100 @@
+package pool
+
+import (
+	"bytes"
+	"math/bits"
+)
+
+// Bytes is a pool of byte slices grouped in power of two size classes.
+type Bytes struct {
+	maxSize int
+	classes []*Pool[[]byte]
+}
+
+// NewBytes returns a byte slice pool for slices up to maxSize bytes, maxSize is
+// rounded up to a power of two. opts apply to every size class.
+func NewBytes(maxSize int, opts ...Option) *Bytes {
+	n := sizeClass(maxSize)
+	b := Bytes{
+		maxSize: 1 << n,
+		classes: make([]*Pool[[]byte], n+1),
+	}
+	for i := range b.classes {
+		size := 1 << i
+		newFn := func() []byte {
+			return make([]byte, size)
+		}
+		b.classes[i] = New(newFn, opts...)
+	}
+
+	return &b
+}
+
+// sizeClass returns the smallest k where 1<<k >= n.
+func sizeClass(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return bits.Len(uint(n - 1))
+}
+
+// Get returns a slice of length n.
+// Slices larger than the pool max size are allocated and not pooled.
+func (b *Bytes) Get(n int) []byte {
+	if n > b.maxSize {
+		return make([]byte, n)
+	}
+
+	buf, _ := b.classes[sizeClass(n)].Get()
+	return buf[:n]
+}
+
+// Put zeroes buf and returns it to the pool.
+// Slices with capacity larger than the pool max size are rejected.
+func (b *Bytes) Put(buf []byte) {
+	c := cap(buf)
+	if c == 0 || c > b.maxSize {
+		return
+	}
+
+	buf = buf[:c]
+	clear(buf)
+	// Round down so every slice in class k has at least 1<<k capacity.
+	k := bits.Len(uint(c)) - 1
+	b.classes[k].Put(buf)
+}
+
+// Buffers is a pool of bytes.Buffer.
+type Buffers struct {
+	maxCap int
+	pool   *Pool[*bytes.Buffer]
+}
+
+// NewBuffers returns a bytes.Buffer pool, buffers that grew over maxCap bytes
+// are not returned to the pool.
+func NewBuffers(maxCap int, opts ...Option) *Buffers {
+	newFn := func() *bytes.Buffer {
+		return new(bytes.Buffer)
+	}
+
+	b := Buffers{
+		maxCap: maxCap,
+		pool:   New(newFn, opts...),
+	}
+	return &b
+}
+
+// Get returns an empty buffer.
+func (b *Buffers) Get() *bytes.Buffer {
+	buf, _ := b.pool.Get()
+	return buf
+}
+
+// Put resets buf and returns it to the pool.
+func (b *Buffers) Put(buf *bytes.Buffer) {
+	if buf.Cap() > b.maxCap {
+		return
+	}
+
+	b.pool.Put(buf)
+}
@@ -0,0 +1,64 @@
+package pool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBytes_SizeClass(t *testing.T) {
+	p := NewBytes(1000, WithMaxSize(1)) // rounded to 1024
+
+	b := p.Get(100)
+	require.Len(t, b, 100)
+	require.Equal(t, 128, cap(b))
+
+	copy(b, "secret")
+	p.Put(b)
+
+	b = p.Get(65)
+	require.Len(t, b, 65)
+	require.Equal(t, 128, cap(b))
+	require.Equal(t, make([]byte, 65), b, "not cleared")
+
+	b = p.Get(1024)
+	require.Equal(t, 1024, cap(b))
+}
+
+func TestBytes_Oversize(t *testing.T) {
+	p := NewBytes(64, WithMaxSize(1))
+
+	b := p.Get(100)
+	require.Len(t, b, 100)
+	p.Put(b) // rejected
+
+	for _, pl := range p.classes {
+		_, ok := pl.store.get()
+		require.False(t, ok)
+	}
+}
+
+func TestBytes_RoundDown(t *testing.T) {
+	p := NewBytes(1024, WithMaxSize(1))
+	p.Put(make([]byte, 100)) // goes to the 64 class
+
+	b := p.Get(64)
+	require.Equal(t, 100, cap(b))
+}
+
+func TestBuffers(t *testing.T) {
+	p := NewBuffers(16, WithMaxSize(1))
+
+	buf := p.Get()
+	buf.WriteString("secret")
+	p.Put(buf)
+
+	buf = p.Get()
+	require.Equal(t, 0, buf.Len(), "not reset")
+
+	buf.Write(make([]byte, 32))
+	p.Put(buf) // over cap, dropped
+
+	_, ok := p.pool.store.get()
+	require.False(t, ok)
+}
@@ -0,0 +1,178 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	stacked "goiface/4_change/challenge"
+)
+
+// Errors reported in debug mode (build with -tags pooldebug).
+var (
+	ErrDoublePut   = errors.New("pool: value put twice")
+	ErrUseAfterPut = errors.New("pool: value used after put")
+	ErrLeak        = errors.New("pool: value not returned")
+)
+
+// poison fills []byte values while they are in the pool.
+const poison = 0xDB
+
+// WithDebugHandler sets the function called on ErrDoublePut and ErrUseAfterPut
+// in debug mode, the default handler panics.
+func WithDebugHandler(fn func(error)) Option {
+	return func(o *options) {
+		o.debugFn = fn
+	}
+}
+
+// tracker tracks values going in and out of the pool in debug mode.
+// Pooled values are kept referenced so their address can't be reused while in
+// the pool, until the store drops them (full bounded store, or garbage
+// collected from the sync.Pool).
+type tracker struct {
+	mu      sync.Mutex
+	out     map[uintptr]error  // key -> acquisition stack
+	in      map[uintptr]pooled // key -> value & put stack
+	handler func(error)
+}
+
+type pooled struct {
+	v   any
+	put error
+}
+
+func newTracker(handler func(error)) *tracker {
+	if handler == nil {
+		handler = func(err error) {
+			panic(err)
+		}
+	}
+
+	t := tracker{
+		out:     make(map[uintptr]error),
+		in:      make(map[uintptr]pooled),
+		handler: handler,
+	}
+	return &t
+}
+
+// identity returns the address of v or 0 if v has no identity (e.g. an int).
+func identity(v any) uintptr {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		return rv.Pointer()
+	case reflect.Slice:
+		if rv.Cap() == 0 {
+			return 0
+		}
+		return rv.Pointer()
+	}
+
+	return 0
+}
+
+// got is called when v leaves the pool.
+func (t *tracker) got(v any) {
+	key := identity(v)
+	if key == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if p, ok := t.in[key]; ok {
+		delete(t.in, key)
+		if b, ok := v.([]byte); ok {
+			b = b[:cap(b)]
+			if !isPoisoned(b) {
+				t.handler(fmt.Errorf("%w: %T\n%+v", ErrUseAfterPut, v, p.put))
+			}
+			clear(b)
+		}
+	}
+
+	t.out[key] = stacked.Wrap(fmt.Errorf("%w: %T", ErrLeak, v))
+}
+
+// put is called when v is returned to the pool, it returns false if v should
+// not be put in the pool.
+func (t *tracker) put(v any) bool {
+	key := identity(v)
+	if key == 0 {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if p, ok := t.in[key]; ok {
+		t.handler(fmt.Errorf("%w: %T\n%+v", ErrDoublePut, v, p.put))
+		return false
+	}
+
+	delete(t.out, key)
+	if b, ok := v.([]byte); ok {
+		b = b[:cap(b)]
+		for i := range b {
+			b[i] = poison
+		}
+	}
+	t.in[key] = pooled{v, stacked.Wrap(fmt.Errorf("put: %T", v))}
+	return true
+}
+
+// drop is called when the store dropped v.
+func (t *tracker) drop(v any) {
+	key := identity(v)
+	if key == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.in, key)
+}
+
+// size returns the number of values tracked in the pool.
+func (t *tracker) size() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.in)
+}
+
+func (t *tracker) leaks() []error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	errs := make([]error, 0, len(t.out))
+	for _, err := range t.out {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func isPoisoned(b []byte) bool {
+	for _, c := range b {
+		if c != poison {
+			return false
+		}
+	}
+	return true
+}
+
+// Leaks returns an ErrLeak error for every value taken with Get and not put
+// back, use "%+v" to print where it was acquired.
+// Leaks returns nil unless built with -tags pooldebug.
+func (p *Pool[T]) Leaks() []error {
+	if p.dbg == nil {
+		return nil
+	}
+
+	return p.dbg.leaks()
+}
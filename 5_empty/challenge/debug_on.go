@@ -0,0 +1,6 @@
+//go:build pooldebug
+
+package pool
+
+// debug is set by building with the pooldebug tag.
+const debug = true
@@ -0,0 +1,78 @@
+//go:build pooldebug
+
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebug_DoublePut(t *testing.T) {
+	var errs []error
+	handler := func(err error) { errs = append(errs, err) }
+	p := New[*int](nil, WithDebugHandler(handler))
+
+	v := new(int)
+	p.Put(v)
+	p.Put(v)
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], ErrDoublePut)
+}
+
+func TestDebug_DoublePutPanics(t *testing.T) {
+	p := New[*int](nil, WithMaxSize(2))
+
+	v := new(int)
+	p.Put(v)
+	require.Panics(t, func() { p.Put(v) })
+}
+
+func TestDebug_UseAfterPut(t *testing.T) {
+	var errs []error
+	handler := func(err error) { errs = append(errs, err) }
+	p := NewBytes(64, WithMaxSize(1), WithDebugHandler(handler))
+
+	b := p.Get(10)
+	p.Put(b)
+	b[3] = 'x' // use after put
+
+	b = p.Get(10)
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], ErrUseAfterPut)
+	require.Equal(t, make([]byte, 10), b, "not cleared")
+}
+
+func TestDebug_Leaks(t *testing.T) {
+	p := New(func() *int { return new(int) })
+
+	v, _ := p.Get()
+	p.Get() // leaked
+	p.Put(v)
+
+	leaks := p.Leaks()
+	require.Len(t, leaks, 1)
+	require.True(t, errors.Is(leaks[0], ErrLeak))
+	require.Contains(t, fmt.Sprintf("%+v", leaks[0]), "TestDebug_Leaks")
+}
+
+func TestDebug_Dropped(t *testing.T) {
+	p := New[*int](nil, WithMaxSize(1))
+	p.Put(new(int))
+	p.Put(new(int)) // dropped, the pool is full
+	require.Equal(t, 1, p.dbg.size())
+
+	// Values the garbage collector drops from the sync.Pool are not kept
+	p = New[*int](nil)
+	for i := 0; i < 100; i++ {
+		p.Put(new(int))
+	}
+	require.Eventually(t, func() bool {
+		runtime.GC()
+		return p.dbg.size() == 0
+	}, time.Second, time.Millisecond)
+}
@@ -24,3 +24,22 @@ func ExamplePool() {
 	// len: 1024, ok=true
 	// len: 0, ok=false
 }
+
+func ExampleWithMaxSize() {
+	p := New[[]byte](nil, WithMaxSize(2), WithStats())
+	for i := 0; i < 3; i++ {
+		p.Put(make([]byte, 1024))
+	}
+
+	for i := 0; i < 3; i++ {
+		b, ok := p.Get()
+		fmt.Printf("len: %d, ok=%v\n", len(b), ok)
+	}
+	fmt.Printf("%+v\n", p.Stats())
+
+	// Output:
+	// len: 1024, ok=true
+	// len: 1024, ok=true
+	// len: 0, ok=false
+	// {Gets:3 Puts:3 Misses:1 News:0 Drops:1 Size:0}
+}
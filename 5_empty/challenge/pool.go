@@ -1,32 +1,233 @@
 package pool
 
-import "sync"
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats are pool usage counters.
+type Stats struct {
+	Gets   int64 // Calls to Get
+	Puts   int64 // Calls to Put
+	Misses int64 // Get calls that found the pool empty
+	News   int64 // Values created by the new function
+	Drops  int64 // Put values discarded since a bounded pool was full
+	Size   int64 // Values currently in the pool
+}
+
+// Option configures a Pool.
+type Option func(*options)
+
+type options struct {
+	stats   bool
+	maxSize int
+	shards  int
+	debugFn func(error)
+}
+
+// WithStats enables usage counters, see Pool.Stats.
+func WithStats() Option {
+	return func(o *options) {
+		o.stats = true
+	}
+}
+
+// WithMaxSize makes the pool bounded: it holds at most n values and never
+// lets the garbage collector drop them. Values put in a full pool are dropped.
+func WithMaxSize(n int) Option {
+	return func(o *options) {
+		o.maxSize = n
+	}
+}
+
+// store holds the pooled values.
+type store interface {
+	put(v any) bool
+	get() (any, bool)
+}
+
+// syncStore is backed by sync.Pool, the garbage collector may drop values.
+type syncStore struct {
+	pool   sync.Pool
+	onDrop func(any) // Called once the garbage collector dropped a value
+}
+
+// dropBox wraps values when onDrop is set, its finalizer runs once sync.Pool
+// dropped it.
+type dropBox struct {
+	v any
+}
+
+func (s *syncStore) put(v any) bool {
+	if s.onDrop == nil {
+		s.pool.Put(v)
+		return true
+	}
+
+	box := &dropBox{v}
+	runtime.SetFinalizer(box, func(box *dropBox) {
+		s.onDrop(box.v)
+	})
+	s.pool.Put(box)
+	return true
+}
+
+func (s *syncStore) get() (any, bool) {
+	v := s.pool.Get()
+	if box, ok := v.(*dropBox); ok {
+		runtime.SetFinalizer(box, nil)
+		v = box.v
+	}
+	return v, v != nil
+}
+
+// chanStore is a bounded store, values are kept until taken out.
+type chanStore struct {
+	items chan any
+}
+
+func (s *chanStore) put(v any) bool {
+	select {
+	case s.items <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *chanStore) get() (any, bool) {
+	select {
+	case v := <-s.items:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// Resetter is implemented by values that can be cleared before going back to
+// the pool.
+type Resetter interface {
+	Reset()
+}
 
 type Pool[T any] struct {
-	pool sync.Pool
+	store store
+	newFn func() T
+	dbg   *tracker // nil unless in debug mode
+
+	stats                           bool
+	gets, puts, misses, news, drops atomic.Int64
+	size                            atomic.Int64
 }
 
-func New[T any](newFn func() T) *Pool[T] {
-	var p Pool[T]
-	if newFn != nil {
-		p.pool.New = func() any {
-			return newFn()
+// New returns a new pool, newFn (if not nil) is used to create values when
+// the pool is empty.
+// By default the pool is backed by a sync.Pool, use WithMaxSize for a bounded
+// pool and WithShards for a sharded one.
+func New[T any](newFn func() T, opts ...Option) *Pool[T] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p := Pool[T]{
+		newFn: newFn,
+		stats: o.stats,
+	}
+
+	switch {
+	case o.shards > 0:
+		p.store = newShardStore(o.shards, o.maxSize)
+	case o.maxSize > 0:
+		p.store = &chanStore{items: make(chan any, o.maxSize)}
+	default:
+		p.store = &syncStore{}
+	}
+
+	if debug {
+		p.dbg = newTracker(o.debugFn)
+		if s, ok := p.store.(*syncStore); ok {
+			s.onDrop = p.dbg.drop
 		}
 	}
 
 	return &p
 }
 
+// Put puts v in the pool, v is reset first if it implements Resetter.
 func (p *Pool[T]) Put(v T) {
-	p.pool.Put(v)
+	if p.dbg != nil && !p.dbg.put(v) {
+		return
+	}
+
+	if r, ok := any(v).(Resetter); ok {
+		r.Reset()
+	}
+
+	ok := p.store.put(v)
+	if !ok && p.dbg != nil {
+		p.dbg.drop(v)
+	}
+
+	if !p.stats {
+		return
+	}
+
+	p.puts.Add(1)
+	if ok {
+		p.size.Add(1)
+	} else {
+		p.drops.Add(1)
+	}
 }
 
 func (p *Pool[T]) Get() (T, bool) {
-	v := p.pool.Get()
-	if v == nil {
-		var zero T
-		return zero, false
+	if p.stats {
+		p.gets.Add(1)
+	}
+
+	v, ok := p.store.get()
+	if ok {
+		if p.stats {
+			p.size.Add(-1)
+		}
+		if p.dbg != nil {
+			p.dbg.got(v)
+		}
+		return v.(T), true
+	}
+
+	if p.stats {
+		p.misses.Add(1)
 	}
 
-	return v.(T), true
+	if p.newFn != nil {
+		if p.stats {
+			p.news.Add(1)
+		}
+		v := p.newFn()
+		if p.dbg != nil {
+			p.dbg.got(v)
+		}
+		return v, true
+	}
+
+	var zero T
+	return zero, false
+}
+
+// Stats returns the pool usage counters, they are all zero unless the pool was
+// created with WithStats.
+// Size of an unbounded pool is an upper bound since the garbage collector may
+// drop values.
+func (p *Pool[T]) Stats() Stats {
+	return Stats{
+		Gets:   p.gets.Load(),
+		Puts:   p.puts.Load(),
+		Misses: p.misses.Load(),
+		News:   p.news.Load(),
+		Drops:  p.drops.Load(),
+		Size:   p.size.Load(),
+	}
 }
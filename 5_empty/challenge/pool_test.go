@@ -0,0 +1,56 @@
+package pool
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool_Stats(t *testing.T) {
+	p := New(func() int { return 7 }, WithStats())
+
+	v, ok := p.Get()
+	require.True(t, ok)
+	require.Equal(t, 7, v)
+
+	p.Put(1)
+	p.Put(2)
+
+	s := p.Stats()
+	require.Equal(t, Stats{Gets: 1, Puts: 2, Misses: 1, News: 1, Size: 2}, s)
+}
+
+func TestPool_NoStats(t *testing.T) {
+	p := New[int](nil)
+	p.Put(1)
+	p.Get()
+	require.Equal(t, Stats{}, p.Stats())
+}
+
+func TestPool_Bounded(t *testing.T) {
+	const size = 4
+	p := New[int](nil, WithMaxSize(size), WithStats())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p.Put(i)
+		}(i)
+	}
+	wg.Wait()
+
+	s := p.Stats()
+	require.Equal(t, int64(size), s.Size)
+	require.Equal(t, int64(100-size), s.Drops)
+
+	for i := 0; i < size; i++ {
+		_, ok := p.Get()
+		require.True(t, ok)
+	}
+	_, ok := p.Get()
+	require.False(t, ok)
+	require.Equal(t, int64(0), p.Stats().Size)
+}
@@ -0,0 +1,114 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// WithShards spreads the pool values over n shards, each with its own lock, to
+// reduce contention when many goroutines use the pool.
+// Get and Put start from the shard of the current P (processor), and steal
+// from (or spill to) the other shards when it is empty (or full). The shard
+// of a P is a hint kept in a sync.Pool, which has a local cache per P.
+// Combined with WithMaxSize, the size is split exactly between the shards,
+// some shards hold no value if it's less than n. Without it, the shards are
+// unbounded. In both cases the garbage collector won't drop values.
+func WithShards(n int) Option {
+	return func(o *options) {
+		o.shards = n
+	}
+}
+
+type shard struct {
+	mu    sync.Mutex
+	items []any
+	max   int      // Maximal number of items, -1 is unbounded
+	_     [64]byte // Avoid false sharing between shards
+}
+
+type shardStore struct {
+	shards []shard
+	hints  sync.Pool // *int, shard index of the current P
+	next   atomic.Int64
+}
+
+func newShardStore(n, maxSize int) *shardStore {
+	s := shardStore{
+		shards: make([]shard, n),
+	}
+	for i := range s.shards {
+		s.shards[i].max = -1
+		if maxSize > 0 {
+			s.shards[i].max = maxSize / n
+			if i < maxSize%n {
+				s.shards[i].max++
+			}
+		}
+	}
+	// New Ps (or hints dropped by the garbage collector) get the next shard
+	s.hints.New = func() any {
+		i := int(s.next.Add(1)-1) % n
+		return &i
+	}
+
+	return &s
+}
+
+// do calls fn on the shards, starting from the one of the current P, until fn
+// returns true. The first pass skips locked shards, the second one (if any
+// were skipped) waits for them.
+func (s *shardStore) do(fn func(sh *shard) bool) bool {
+	hint := s.hints.Get().(*int)
+	start := *hint
+	s.hints.Put(hint)
+
+	skipped := true
+	for pass := 0; pass < 2 && skipped; pass++ {
+		skipped = false
+		for i := range s.shards {
+			sh := &s.shards[(start+i)%len(s.shards)]
+			if pass == 0 {
+				if !sh.mu.TryLock() {
+					skipped = true
+					continue
+				}
+			} else {
+				sh.mu.Lock()
+			}
+
+			ok := fn(sh)
+			sh.mu.Unlock()
+			if ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *shardStore) put(v any) bool {
+	return s.do(func(sh *shard) bool {
+		if sh.max >= 0 && len(sh.items) >= sh.max {
+			return false
+		}
+		sh.items = append(sh.items, v)
+		return true
+	})
+}
+
+func (s *shardStore) get() (any, bool) {
+	var v any
+	ok := s.do(func(sh *shard) bool {
+		n := len(sh.items)
+		if n == 0 {
+			return false
+		}
+		v = sh.items[n-1]
+		sh.items[n-1] = nil
+		sh.items = sh.items[:n-1]
+		return true
+	})
+
+	return v, ok
+}
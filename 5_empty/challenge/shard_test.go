@@ -0,0 +1,66 @@
+package pool
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShards_Steal(t *testing.T) {
+	const n = 8
+	p := New[int](nil, WithShards(4), WithMaxSize(n), WithStats())
+
+	for i := 0; i < n+2; i++ {
+		p.Put(i)
+	}
+	require.Equal(t, int64(n), p.Stats().Size)
+	require.Equal(t, int64(2), p.Stats().Drops)
+
+	// Gets must find values in every shard
+	seen := make(map[int]bool)
+	for i := 0; i < n; i++ {
+		v, ok := p.Get()
+		require.True(t, ok)
+		seen[v] = true
+	}
+	require.Len(t, seen, n)
+
+	_, ok := p.Get()
+	require.False(t, ok)
+}
+
+func TestShards_Concurrent(t *testing.T) {
+	p := New(func() *int { return new(int) }, WithShards(4), WithStats())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				v, _ := p.Get()
+				p.Put(v)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := p.Stats()
+	require.Equal(t, int64(16*1000), s.Gets)
+	require.Equal(t, s.Gets, s.Puts)
+	// A Get can miss a value Put in a shard it already scanned, so News is not
+	// bounded by the number of goroutines
+	require.LessOrEqual(t, s.News, s.Gets)
+	require.Equal(t, s.News, s.Size)
+}
+
+func TestShards_MaxSize(t *testing.T) {
+	for _, tc := range []struct{ shards, max int }{{4, 2}, {4, 7}, {3, 9}, {8, 1}} {
+		p := New[*int](nil, WithShards(tc.shards), WithMaxSize(tc.max), WithStats())
+		for i := 0; i < 2*tc.max+tc.shards; i++ {
+			p.Put(new(int))
+		}
+		require.Equal(t, int64(tc.max), p.Stats().Size, "%d shards, max %d", tc.shards, tc.max)
+	}
+}
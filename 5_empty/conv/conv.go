@@ -0,0 +1,361 @@
+package conv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+var (
+	ErrOverflow    = errors.New("value out of range")
+	ErrUnsupported = errors.New("unsupported conversion")
+	ErrNil         = errors.New("nil value")
+)
+
+// Error is returned when a value can't be converted.
+type Error struct {
+	Path  string       // Location of the value in a slice or map, e.g. "[2]"
+	Value any          // Value that failed conversion
+	Type  reflect.Type // Target type
+	Err   error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("can't convert %#v (%T) to %s - %s", e.Value, e.Value, e.Type, e.Err)
+	if e.Path != "" {
+		return e.Path + ": " + msg
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// As converts v to T.
+// Numbers are converted with range checks, strings are parsed to numbers and
+// booleans (and numbers formatted to strings), slices and maps are converted
+// element by element.
+func As[T any](v any) (T, error) {
+	var out T
+	rv, err := Convert(v, reflect.TypeOf(&out).Elem())
+	if err != nil {
+		return out, err
+	}
+
+	// A nil interface (e.g. As[any](nil)) is the zero T
+	out, _ = rv.Interface().(T)
+	return out, nil
+}
+
+// Convert converts v to a value of type typ, see As.
+func Convert(v any, typ reflect.Type) (reflect.Value, error) {
+	return convert(reflect.ValueOf(v), typ)
+}
+
+func convert(v reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	// Unwrap pointers & interfaces unless we can use them as is
+	for v.IsValid() && !v.Type().AssignableTo(typ) && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		v = v.Elem()
+	}
+
+	if !v.IsValid() || isNilValue(v) {
+		switch typ.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			return reflect.Zero(typ), nil
+		}
+		return reflect.Value{}, newError(v, typ, ErrNil)
+	}
+
+	if v.Type().AssignableTo(typ) {
+		out := reflect.New(typ).Elem()
+		out.Set(v)
+		return out, nil
+	}
+
+	out := reflect.New(typ).Elem()
+	var err error
+	switch typ.Kind() {
+	case reflect.Bool:
+		err = toBool(v, out)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		err = toInt(v, out)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		err = toUint(v, out)
+	case reflect.Float32, reflect.Float64:
+		err = toFloat(v, out)
+	case reflect.String:
+		err = toString(v, out)
+	case reflect.Slice:
+		return toSlice(v, typ)
+	case reflect.Map:
+		return toMap(v, typ)
+	case reflect.Pointer:
+		elem, err := convert(v, typ.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out.Set(reflect.New(typ.Elem()))
+		out.Elem().Set(elem)
+		return out, nil
+	default:
+		err = ErrUnsupported
+	}
+
+	if err != nil {
+		return reflect.Value{}, newError(v, typ, err)
+	}
+
+	return out, nil
+}
+
+func newError(v reflect.Value, typ reflect.Type, err error) *Error {
+	var val any
+	if v.IsValid() && v.CanInterface() {
+		val = v.Interface()
+	}
+
+	return &Error{Value: val, Type: typ, Err: err}
+}
+
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	}
+	return false
+}
+
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isFloat(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+// numError returns the underlying strconv error (e.g. strconv.ErrSyntax).
+func numError(err error) error {
+	var nerr *strconv.NumError
+	if errors.As(err, &nerr) {
+		if nerr.Err == strconv.ErrRange {
+			return ErrOverflow
+		}
+		return nerr.Err
+	}
+	return err
+}
+
+func toBool(v, out reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		out.SetBool(v.Bool())
+	case reflect.String:
+		b, err := strconv.ParseBool(v.String())
+		if err != nil {
+			return numError(err)
+		}
+		out.SetBool(b)
+	default:
+		return ErrUnsupported
+	}
+
+	return nil
+}
+
+func toInt(v, out reflect.Value) error {
+	var n int64
+	k := v.Kind()
+	switch {
+	case isInt(k):
+		n = v.Int()
+	case isUint(k):
+		u := v.Uint()
+		if u > math.MaxInt64 {
+			return ErrOverflow
+		}
+		n = int64(u)
+	case isFloat(k):
+		f := v.Float()
+		if f != math.Trunc(f) {
+			return fmt.Errorf("%v is not an integer", f)
+		}
+		if f < math.MinInt64 || f >= math.MaxInt64 {
+			return ErrOverflow
+		}
+		n = int64(f)
+	case k == reflect.String:
+		var err error
+		n, err = strconv.ParseInt(v.String(), 0, 64)
+		if err != nil {
+			return numError(err)
+		}
+	default:
+		return ErrUnsupported
+	}
+
+	if out.OverflowInt(n) {
+		return ErrOverflow
+	}
+	out.SetInt(n)
+	return nil
+}
+
+func toUint(v, out reflect.Value) error {
+	var n uint64
+	k := v.Kind()
+	switch {
+	case isInt(k):
+		i := v.Int()
+		if i < 0 {
+			return ErrOverflow
+		}
+		n = uint64(i)
+	case isUint(k):
+		n = v.Uint()
+	case isFloat(k):
+		f := v.Float()
+		if f != math.Trunc(f) {
+			return fmt.Errorf("%v is not an integer", f)
+		}
+		if f < 0 || f >= math.MaxUint64 {
+			return ErrOverflow
+		}
+		n = uint64(f)
+	case k == reflect.String:
+		var err error
+		n, err = strconv.ParseUint(v.String(), 0, 64)
+		if err != nil {
+			return numError(err)
+		}
+	default:
+		return ErrUnsupported
+	}
+
+	if out.OverflowUint(n) {
+		return ErrOverflow
+	}
+	out.SetUint(n)
+	return nil
+}
+
+func toFloat(v, out reflect.Value) error {
+	var f float64
+	k := v.Kind()
+	switch {
+	case isInt(k):
+		f = float64(v.Int())
+	case isUint(k):
+		f = float64(v.Uint())
+	case isFloat(k):
+		f = v.Float()
+	case k == reflect.String:
+		var err error
+		f, err = strconv.ParseFloat(v.String(), 64)
+		if err != nil {
+			return numError(err)
+		}
+	default:
+		return ErrUnsupported
+	}
+
+	if !math.IsInf(f, 0) && out.OverflowFloat(f) {
+		return ErrOverflow
+	}
+	out.SetFloat(f)
+	return nil
+}
+
+func toString(v, out reflect.Value) error {
+	k := v.Kind()
+	switch {
+	case k == reflect.String:
+		out.SetString(v.String())
+	case k == reflect.Bool:
+		out.SetString(strconv.FormatBool(v.Bool()))
+	case isInt(k):
+		out.SetString(strconv.FormatInt(v.Int(), 10))
+	case isUint(k):
+		out.SetString(strconv.FormatUint(v.Uint(), 10))
+	case isFloat(k):
+		out.SetString(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	case k == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		out.SetString(string(v.Bytes()))
+	default:
+		return ErrUnsupported
+	}
+
+	return nil
+}
+
+func toSlice(v reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	// string -> []byte
+	if v.Kind() == reflect.String && typ.Elem().Kind() == reflect.Uint8 {
+		b := []byte(v.String())
+		if reflect.TypeOf(b).ConvertibleTo(typ) {
+			return reflect.ValueOf(b).Convert(typ), nil
+		}
+
+		// Named byte elements, e.g. []Level
+		out := reflect.MakeSlice(typ, len(b), len(b))
+		for i, c := range b {
+			out.Index(i).SetUint(uint64(c))
+		}
+		return out, nil
+	}
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return reflect.Value{}, newError(v, typ, ErrUnsupported)
+	}
+
+	out := reflect.MakeSlice(typ, v.Len(), v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem, err := convert(v.Index(i), typ.Elem())
+		if err != nil {
+			return reflect.Value{}, withPath(err, fmt.Sprintf("[%d]", i))
+		}
+		out.Index(i).Set(elem)
+	}
+
+	return out, nil
+}
+
+func toMap(v reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	if v.Kind() != reflect.Map {
+		return reflect.Value{}, newError(v, typ, ErrUnsupported)
+	}
+
+	out := reflect.MakeMapWithSize(typ, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		path := fmt.Sprintf("[%v]", iter.Key())
+		key, err := convert(iter.Key(), typ.Key())
+		if err != nil {
+			return reflect.Value{}, withPath(err, path)
+		}
+
+		val, err := convert(iter.Value(), typ.Elem())
+		if err != nil {
+			return reflect.Value{}, withPath(err, path)
+		}
+		out.SetMapIndex(key, val)
+	}
+
+	return out, nil
+}
+
+// withPath prepends path to err path.
+func withPath(err error, path string) error {
+	var cerr *Error
+	if errors.As(err, &cerr) {
+		cerr.Path = path + cerr.Path
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package conv
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type Level byte
+
+var convertCases = []struct {
+	name string
+	v    any
+	typ  reflect.Type
+	out  any
+	err  error
+}{
+	{"same", 7, reflect.TypeOf(0), 7, nil},
+	{"widen", int8(-3), reflect.TypeOf(int64(0)), int64(-3), nil},
+	{"narrow", int64(200), reflect.TypeOf(uint8(0)), uint8(200), nil},
+	{"narrow overflow", int64(256), reflect.TypeOf(uint8(0)), nil, ErrOverflow},
+	{"negative uint", -1, reflect.TypeOf(uint(0)), nil, ErrOverflow},
+	{"uint to int overflow", uint64(math.MaxUint64), reflect.TypeOf(0), nil, ErrOverflow},
+	{"float to int", 42.0, reflect.TypeOf(0), 42, nil},
+	{"float overflow", 1e20, reflect.TypeOf(int32(0)), nil, ErrOverflow},
+	{"float32 overflow", 1e300, reflect.TypeOf(float32(0)), nil, ErrOverflow},
+	{"int to float", 3, reflect.TypeOf(0.0), 3.0, nil},
+	{"named", 2, reflect.TypeOf(Level(0)), Level(2), nil},
+	{"parse int", "0x10", reflect.TypeOf(0), 16, nil},
+	{"parse bad int", "ten", reflect.TypeOf(0), nil, strconv.ErrSyntax},
+	{"parse float", "2.5", reflect.TypeOf(0.0), 2.5, nil},
+	{"parse bool", "true", reflect.TypeOf(false), true, nil},
+	{"format int", 12, reflect.TypeOf(""), "12", nil},
+	{"format float", 1.5, reflect.TypeOf(""), "1.5", nil},
+	{"bytes to string", []byte("hi"), reflect.TypeOf(""), "hi", nil},
+	{"string to bytes", "hi", reflect.TypeOf([]byte(nil)), []byte("hi"), nil},
+	{"string to named bytes", "hi", reflect.TypeOf([]Level(nil)), []Level{'h', 'i'}, nil},
+	{"bool to int", true, reflect.TypeOf(0), nil, ErrUnsupported},
+	{"nil to int", nil, reflect.TypeOf(0), nil, ErrNil},
+	{"nil to slice", nil, reflect.TypeOf([]int(nil)), []int(nil), nil},
+	{"nil to any", nil, reflect.TypeOf((*any)(nil)).Elem(), nil, nil},
+	{"nil to error", nil, reflect.TypeOf((*error)(nil)).Elem(), nil, nil},
+	{"pointer", 3, reflect.TypeOf(new(int)), nil, nil},
+	{"deref", new(int), reflect.TypeOf(0.0), 0.0, nil},
+	{"map", map[string]any{"a": "1"}, reflect.TypeOf(map[string]int{}), map[string]int{"a": 1}, nil},
+	{"map error", map[string]any{"a": "x"}, reflect.TypeOf(map[string]int{}), nil, strconv.ErrSyntax},
+	{"array to slice", [2]int{1, 2}, reflect.TypeOf([]string{}), []string{"1", "2"}, nil},
+}
+
+func TestConvert(t *testing.T) {
+	for _, tc := range convertCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := Convert(tc.v, tc.typ)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				var cerr *Error
+				require.True(t, errors.As(err, &cerr))
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.typ, out.Type())
+			if tc.out != nil {
+				require.Equal(t, tc.out, out.Interface())
+			}
+		})
+	}
+}
+
+func TestAs_Nil(t *testing.T) {
+	v, err := As[any](nil)
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	e, err := As[error](nil)
+	require.NoError(t, err)
+	require.Nil(t, e)
+}
+
+func TestAs_Path(t *testing.T) {
+	_, err := As[map[string][]int](map[string]any{"ports": []any{1, "x"}})
+	require.EqualError(t, err, `[ports][1]: can't convert "x" (string) to int - invalid syntax`)
+}
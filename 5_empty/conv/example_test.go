@@ -0,0 +1,33 @@
+package conv
+
+import (
+	"fmt"
+	"sync"
+)
+
+func ExampleAs() {
+	var pool sync.Pool
+	pool.Put(make([]byte, 1024))
+
+	buf, err := As[[]byte](pool.Get())
+	fmt.Println(len(buf), err)
+
+	_, err = As[[]byte](1024)
+	fmt.Println(err)
+
+	n, err := As[uint8]("300")
+	fmt.Println(n, err)
+
+	ports, err := As[[]int]([]any{"8080", 9090.0})
+	fmt.Println(ports, err)
+
+	ports, err = As[[]int]([]any{8080, 1.5})
+	fmt.Println(ports, err)
+
+	// Output:
+	// 1024 <nil>
+	// can't convert 1024 (int) to []uint8 - unsupported conversion
+	// 0 can't convert "300" (string) to uint8 - value out of range
+	// [8080 9090] <nil>
+	// [] [1]: can't convert 1.5 (float64) to int - 1.5 is not an integer
+}
@@ -0,0 +1,349 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// Change is a difference between two values at Path (e.g. "Parent.Scores[1]").
+type Change struct {
+	Path string
+	A    string // formatted value, "<missing>" if not in A
+	B    string // formatted value, "<missing>" if not in B
+}
+
+// String implements fmt.Stringer
+func (c Change) String() string {
+	if c.Path == "" {
+		return fmt.Sprintf("%s != %s", c.A, c.B)
+	}
+	return fmt.Sprintf("%s: %s != %s", c.Path, c.A, c.B)
+}
+
+// Changes is a list of changes.
+type Changes []Change
+
+// String implements fmt.Stringer, one change per line.
+func (cs Changes) String() string {
+	var sb strings.Builder
+	for _, c := range cs {
+		sb.WriteString(c.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Option configures Diff.
+type Option func(*config)
+
+type config struct {
+	ignore   map[string]bool
+	timeTol  time.Duration
+	nilEmpty bool
+}
+
+// IgnoreFields ignores struct fields, either by name (e.g. "Time") anywhere in
+// the value or by path (e.g. "Parent.Time").
+func IgnoreFields(names ...string) Option {
+	return func(c *config) {
+		for _, name := range names {
+			c.ignore[name] = true
+		}
+	}
+}
+
+// TimeTolerance considers time.Time values equal if they are less than d apart.
+func TimeTolerance(d time.Duration) Option {
+	return func(c *config) {
+		c.timeTol = d
+	}
+}
+
+// NilEmptyEqual considers nil and empty slices or maps equal.
+func NilEmptyEqual() Option {
+	return func(c *config) {
+		c.nilEmpty = true
+	}
+}
+
+const missing = "<missing>"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Diff returns the differences between a and b, it returns nil if they are
+// equal.
+func Diff(a, b any, opts ...Option) Changes {
+	d := differ{
+		cfg:     config{ignore: make(map[string]bool)},
+		visited: make(map[visit]bool),
+	}
+	for _, opt := range opts {
+		opt(&d.cfg)
+	}
+
+	d.diff(addressable(a), addressable(b), "")
+	return d.changes
+}
+
+// Equal returns true if Diff finds no differences.
+func Equal(a, b any, opts ...Option) bool {
+	return len(Diff(a, b, opts...)) == 0
+}
+
+// addressable returns an addressable copy of v so unexported time.Time fields
+// can be read.
+func addressable(v any) reflect.Value {
+	if v == nil {
+		return reflect.Value{}
+	}
+
+	rv := reflect.New(reflect.TypeOf(v)).Elem()
+	rv.Set(reflect.ValueOf(v))
+	return rv
+}
+
+type visit struct {
+	a, b uintptr
+	typ  reflect.Type
+	len  int // Slices with the same data can have different lengths
+}
+
+type differ struct {
+	cfg     config
+	changes Changes
+	visited map[visit]bool // avoid cycles
+}
+
+func (d *differ) add(path string, a, b string) {
+	d.changes = append(d.changes, Change{path, a, b})
+}
+
+func (d *differ) diff(a, b reflect.Value, path string) {
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			d.add(path, format(a), format(b))
+		}
+		return
+	}
+
+	if a.Type() != b.Type() {
+		d.add(path, formatType(a), formatType(b))
+		return
+	}
+
+	if a.Type() == timeType {
+		ta, oka := valueTime(a)
+		tb, okb := valueTime(b)
+		if oka && okb {
+			d.diffTime(ta, tb, path)
+			return
+		}
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(path, format(a), format(b))
+			}
+			return
+		}
+
+		if a.Kind() == reflect.Pointer && d.seen(a, b) {
+			return
+		}
+		d.diff(a.Elem(), b.Elem(), path)
+	case reflect.Struct:
+		d.diffStruct(a, b, path)
+	case reflect.Slice, reflect.Array:
+		// A slice or a map can contain itself through an interface
+		if a.Kind() == reflect.Slice && d.seen(a, b) {
+			return
+		}
+		d.diffSlice(a, b, path)
+	case reflect.Map:
+		if d.seen(a, b) {
+			return
+		}
+		d.diffMap(a, b, path)
+	default:
+		if !equalScalar(a, b) {
+			d.add(path, format(a), format(b))
+		}
+	}
+}
+
+// seen returns true if the pointers, slices or maps a and b were already
+// compared, and marks them as compared.
+func (d *differ) seen(a, b reflect.Value) bool {
+	if a.Pointer() == 0 || b.Pointer() == 0 {
+		return false // nil
+	}
+
+	v := visit{a.Pointer(), b.Pointer(), a.Type(), 0}
+	if a.Kind() == reflect.Slice {
+		v.len = a.Len()
+	}
+	if d.visited[v] {
+		return true
+	}
+	d.visited[v] = true
+	return false
+}
+
+func (d *differ) diffTime(ta, tb time.Time, path string) {
+	delta := ta.Sub(tb)
+	if delta < 0 {
+		delta = -delta
+	}
+
+	if ta.Equal(tb) || (d.cfg.timeTol > 0 && delta < d.cfg.timeTol) {
+		return
+	}
+	// Round(0) strips the monotonic clock reading
+	d.add(path, ta.Round(0).String(), tb.Round(0).String())
+}
+
+// valueTime returns the time in v, v can be an unexported field if it's
+// addressable.
+func valueTime(v reflect.Value) (time.Time, bool) {
+	if v.CanInterface() {
+		return v.Interface().(time.Time), true
+	}
+
+	if v.CanAddr() {
+		v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+		return v.Interface().(time.Time), true
+	}
+
+	return time.Time{}, false
+}
+
+func (d *differ) diffStruct(a, b reflect.Value, path string) {
+	typ := a.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Name
+		fieldPath := joinPath(path, name)
+		if d.cfg.ignore[name] || d.cfg.ignore[fieldPath] {
+			continue
+		}
+
+		d.diff(a.Field(i), b.Field(i), fieldPath)
+	}
+}
+
+func (d *differ) diffSlice(a, b reflect.Value, path string) {
+	if a.Kind() == reflect.Slice {
+		if d.cfg.nilEmpty && a.Len() == 0 && b.Len() == 0 {
+			return
+		}
+
+		if a.IsNil() != b.IsNil() {
+			d.add(path, format(a), format(b))
+			return
+		}
+	}
+
+	n := max(a.Len(), b.Len())
+	for i := 0; i < n; i++ {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= a.Len():
+			d.add(elemPath, missing, format(b.Index(i)))
+		case i >= b.Len():
+			d.add(elemPath, format(a.Index(i)), missing)
+		default:
+			d.diff(a.Index(i), b.Index(i), elemPath)
+		}
+	}
+}
+
+func (d *differ) diffMap(a, b reflect.Value, path string) {
+	if d.cfg.nilEmpty && a.Len() == 0 && b.Len() == 0 {
+		return
+	}
+
+	if a.IsNil() != b.IsNil() {
+		d.add(path, format(a), format(b))
+		return
+	}
+
+	keys := a.MapKeys()
+	for _, k := range b.MapKeys() {
+		if !a.MapIndex(k).IsValid() {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+
+	for _, k := range keys {
+		elemPath := fmt.Sprintf("%s[%v]", path, k)
+		va, vb := a.MapIndex(k), b.MapIndex(k)
+		switch {
+		case !va.IsValid():
+			d.add(elemPath, missing, format(vb))
+		case !vb.IsValid():
+			d.add(elemPath, format(va), missing)
+		default:
+			d.diff(va, vb, elemPath)
+		}
+	}
+}
+
+func equalScalar(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	case reflect.Func:
+		// Like reflect.DeepEqual, functions are equal only if both nil
+		return a.IsNil() && b.IsNil()
+	}
+
+	return false
+}
+
+func format(v reflect.Value) string {
+	if !v.IsValid() {
+		return "<nil>"
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("%q", v)
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return "<nil>"
+		}
+	}
+
+	return fmt.Sprintf("%v", v)
+}
+
+func formatType(v reflect.Value) string {
+	return fmt.Sprintf("%s (%s)", format(v), v.Type())
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,107 @@
+package diff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type node struct {
+	Value int
+	Next  *node
+	when  time.Time
+}
+
+type record struct {
+	Key    uint
+	Data   []byte
+	Attrs  map[string]any
+	Parent *record
+}
+
+func TestDiff_Equal(t *testing.T) {
+	a := record{Key: 1, Data: []byte("a"), Attrs: map[string]any{"x": 1}}
+	b := record{Key: 1, Data: []byte("a"), Attrs: map[string]any{"x": 1}}
+	require.Nil(t, Diff(a, b))
+	require.True(t, Equal(&a, &b))
+	require.True(t, Equal(nil, nil))
+}
+
+func TestDiff_Paths(t *testing.T) {
+	a := record{
+		Key:    1,
+		Data:   []byte("ab"),
+		Attrs:  map[string]any{"x": 1, "y": "z", "old": true},
+		Parent: &record{Key: 2},
+	}
+	b := record{
+		Key:    1,
+		Data:   []byte("abc"),
+		Attrs:  map[string]any{"x": 2, "y": 3, "new": true},
+		Parent: &record{Key: 3},
+	}
+
+	expected := Changes{
+		{"Data[2]", missing, "99"},
+		{"Attrs[new]", missing, "true"},
+		{"Attrs[old]", "true", missing},
+		{"Attrs[x]", "1", "2"},
+		{"Attrs[y]", `"z" (string)`, "3 (int)"},
+		{"Parent.Key", "2", "3"},
+	}
+	require.Equal(t, expected, Diff(a, b))
+}
+
+func TestDiff_Options(t *testing.T) {
+	a := record{Key: 1, Parent: &record{Key: 2}}
+	b := record{Key: 1, Attrs: map[string]any{}, Parent: &record{Key: 3}}
+
+	require.Len(t, Diff(a, b), 2)
+	require.Len(t, Diff(a, b, NilEmptyEqual()), 1)
+	require.Nil(t, Diff(a, b, NilEmptyEqual(), IgnoreFields("Parent.Key")))
+	require.Len(t, Diff(a, b, NilEmptyEqual(), IgnoreFields("Data.Key")), 1)
+	require.Nil(t, Diff(a, b, NilEmptyEqual(), IgnoreFields("Key")))
+}
+
+func TestDiff_Cycle(t *testing.T) {
+	now := time.Now()
+	a := &node{Value: 1, when: now}
+	a.Next = a
+	b := &node{Value: 1, when: now.Add(time.Millisecond)}
+	b.Next = b
+
+	require.Equal(t, []string{"when"}, paths(Diff(a, b)))
+	require.Nil(t, Diff(a, b, TimeTolerance(time.Second)))
+
+	b.Value = 2
+	require.Equal(t, []string{"Value"}, paths(Diff(a, b, TimeTolerance(time.Second))))
+}
+
+func paths(cs Changes) []string {
+	var out []string
+	for _, c := range cs {
+		out = append(out, c.Path)
+	}
+	return out
+}
+
+func TestDiff_CycleMapSlice(t *testing.T) {
+	ma := map[string]any{"n": 1}
+	ma["self"] = ma
+	mb := map[string]any{"n": 1}
+	mb["self"] = mb
+	require.Nil(t, Diff(ma, mb))
+
+	mb["n"] = 2
+	require.Equal(t, []string{"[n]"}, paths(Diff(ma, mb)))
+
+	sa := []any{1, nil}
+	sa[1] = sa
+	sb := []any{1, nil}
+	sb[1] = sb
+	require.Nil(t, Diff(sa, sb))
+
+	sb[0] = 2
+	require.Equal(t, []string{"[0]"}, paths(Diff(sa, sb)))
+}
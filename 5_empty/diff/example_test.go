@@ -0,0 +1,38 @@
+package diff
+
+import (
+	"fmt"
+	"time"
+)
+
+type AccessEvent struct {
+	Time   time.Time
+	Login  string
+	URI    string
+	Action string
+	Tags   []string
+}
+
+func ExampleDiff() {
+	now := time.Date(2024, 2, 11, 20, 27, 31, 0, time.UTC)
+	a := AccessEvent{Time: now, Login: "joe", URI: "/etc/passwd", Action: "read"}
+	b := AccessEvent{
+		Time:   now.Add(time.Millisecond),
+		Login:  "joe",
+		URI:    "/etc/shadow",
+		Action: "read",
+		Tags:   []string{},
+	}
+
+	fmt.Print(Diff(a, b))
+	fmt.Println("---")
+	fmt.Print(Diff(a, b, TimeTolerance(time.Second), NilEmptyEqual(), IgnoreFields("URI")))
+	fmt.Println("---")
+
+	// Output:
+	// Time: 2024-02-11 20:27:31 +0000 UTC != 2024-02-11 20:27:31.001 +0000 UTC
+	// URI: "/etc/passwd" != "/etc/shadow"
+	// Tags: <nil> != []
+	// ---
+	// ---
+}